@@ -0,0 +1,587 @@
+// Package client is the Go client of the HTTP API of tipsd
+//
+// The messages of a subscription can be streamed by the gRPC API as well,
+// whose client is generated in the tipspb package.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Client calls the HTTP API of tipsd
+type Client struct {
+	addr string
+	// HTTPClient sends the requests, http.DefaultClient is used if it is nil
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client of tipsd listening on addr, such as http://127.0.0.1:7369
+func NewClient(addr string) *Client {
+	return &Client{addr: strings.TrimSuffix(addr, "/")}
+}
+
+// Error is returned when tipsd responds with a status other than 200
+type Error struct {
+	StatusCode int
+	// Code is the machine-readable code of the error, such as not_found, it is
+	// empty if tipsd does not respond with one
+	Code   string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tips: %s (%d)", e.Reason, e.StatusCode)
+}
+
+// IsNotFound returns true if the error is caused by a missing topic, subscription,
+// snapshot or message
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// IsRetryable returns true if a request may succeed when it is retried, such as the
+// network failures and timeouts, the server errors, the transaction conflicts and the
+// throttling. The other errors, such as a malformed response, are not retryable.
+func IsRetryable(err error) bool {
+	switch e := err.(type) {
+	case *Error:
+		switch e.StatusCode {
+		case http.StatusConflict, http.StatusTooManyRequests:
+			return true
+		}
+		return e.StatusCode >= http.StatusInternalServerError
+	case *url.Error:
+		_, ok := e.Err.(net.Error)
+		return ok
+	case net.Error:
+		return true
+	}
+	return false
+}
+
+// newError creates an error from a response body, which is an Error{Code, Reason} of tipsd
+// in most cases, but it may be a JSON string or plain text as well
+func newError(code int, body []byte) *Error {
+	e := &Error{StatusCode: code}
+	reason := &struct {
+		Code   string
+		Reason string
+	}{}
+	var text string
+	if err := json.Unmarshal(body, reason); err == nil && reason.Reason != "" {
+		e.Code, e.Reason = reason.Code, reason.Reason
+	} else if err := json.Unmarshal(body, &text); err == nil {
+		e.Reason = text
+	} else {
+		e.Reason = strings.TrimSpace(string(body))
+	}
+	if e.Reason == "" {
+		e.Reason = http.StatusText(code)
+	}
+	return e
+}
+
+// Retention is the retention policy of a topic
+type Retention struct {
+	// MaxAge trims messages older than it, 0 means no limit, it is truncated to seconds
+	MaxAge time.Duration
+	// MaxCount keeps at most MaxCount latest messages, 0 means no limit
+	MaxCount int64
+	// Acked trims messages which have been acked by all subscriptions
+	Acked bool
+}
+
+// Topic is a topic of tipsd
+type Topic struct {
+	Name      string
+	ObjectID  []byte
+	CreatedAt int64
+	Retention *Retention
+	// TTL is the time to live of the messages published without one, 0 means they never expire
+	TTL time.Duration
+	// Partitions is the number of key ranges the messages are spread over
+	Partitions int
+}
+
+// Offset is the position of a message in a topic
+type Offset struct {
+	TS    int64
+	Index int64
+}
+
+// Subscription is a subscription of a topic
+type Subscription struct {
+	Name                string
+	Sent                *Offset
+	Acked               *Offset
+	DeadLetterTopic     string
+	MaxDeliveryAttempts int
+	Filter              string
+	PushEndpoint        string
+	Ordered             bool
+}
+
+// SubscriptionInfo is a subscription with its backlog
+type SubscriptionInfo struct {
+	Subscription
+	// Head is the offset of the latest message of the topic
+	Head *Offset
+	// Unacked is the sum of Outstanding and Undelivered
+	Unacked int64
+	// Outstanding is the number of messages sent but not acked
+	Outstanding int64
+	// Undelivered is the number of messages which have not been sent
+	Undelivered int64
+	// OldestUnacked is the offset of the earliest unacked message
+	OldestUnacked    *Offset
+	OldestUnackedAge time.Duration
+	// Truncated is true if the backlog is too large to be counted, the counts
+	// and the Head are lower bounds then
+	Truncated bool
+}
+
+// SubscribeOptions are the options of a subscription, the empty ones are not updated
+type SubscribeOptions struct {
+	// DeadLetterTopic receives the messages which have been delivered
+	// MaxDeliveryAttempts times but not acked
+	DeadLetterTopic     string `json:",omitempty"`
+	MaxDeliveryAttempts int    `json:",omitempty"`
+	Filter              string `json:",omitempty"`
+	// ClearFilter removes the filter of the subscription, Filter should be empty then
+	ClearFilter  bool   `json:"-"`
+	PushEndpoint string `json:",omitempty"`
+	// ClearPushEndpoint makes a push subscription a pull one, PushEndpoint should be empty then
+	ClearPushEndpoint bool `json:"-"`
+	// Ordered delivers the messages with the same ordering key one by one, a message
+	// is not delivered until the earlier ones with the key are acked
+	Ordered bool `json:",omitempty"`
+	// Start is where the subscription starts if it is created
+	Start *StartPosition `json:",omitempty"`
+}
+
+// StartPosition is where a new subscription starts, at most one of the fields is
+// set and it starts from the latest message if none is set
+type StartPosition struct {
+	// Earliest starts from the earliest message retained by the topic
+	Earliest bool `json:",omitempty"`
+	// Time starts from the messages published at or after it
+	Time *time.Time `json:",omitempty"`
+	// Offset starts from the messages after it, such as the id of a message
+	Offset string `json:",omitempty"`
+	// Snapshot starts from a snapshot of SnapshotSubscription
+	Snapshot             string `json:",omitempty"`
+	SnapshotSubscription string `json:",omitempty"`
+}
+
+// Snapshot is a snapshot of a subscription
+type Snapshot struct {
+	Name         string
+	Subscription *Subscription
+}
+
+// ListOptions are the options of listing topics, subscriptions or snapshots
+type ListOptions struct {
+	// Prefix filters the items by name
+	Prefix string
+	// Token is the next token returned by the previous page, empty for the first page
+	Token string
+	// Limit is the max items in a page, 100 if it is 0
+	Limit int
+}
+
+// query encodes the options as the query of a list request
+func (opts *ListOptions) query() string {
+	if opts == nil {
+		return ""
+	}
+	q := url.Values{}
+	if opts.Prefix != "" {
+		q.Set("prefix", opts.Prefix)
+	}
+	if opts.Token != "" {
+		q.Set("token", opts.Token)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// Message is a message published to or pulled from tipsd
+type Message struct {
+	// ID is set by tipsd, it is ignored when publishing
+	ID         string
+	Payload    []byte
+	Attributes map[string]string
+	// PublishTime is the time in unix nanoseconds when the message is published
+	PublishTime int64
+	// DeliveryAttempt is the number of times the message has been delivered to the subscription
+	DeliveryAttempt int
+	// DedupKey makes a retried publish idempotent, a message whose key has been
+	// published within the dedup window of tipsd is not written again
+	DedupKey string
+	// OrderingKey groups the messages which are delivered in order by an ordered subscription
+	OrderingKey string
+	// DeliverAt delays the message until the time, it is not delayed if it is zero
+	DeliverAt time.Time
+	// Delay delays the message for the duration after publishing, it is sent in seconds.
+	// At most one of DeliverAt and Delay can be set
+	Delay time.Duration
+	// TTL drops the message if it is not delivered in the duration after it is visible,
+	// the TTL of the topic is used if it is 0. It is sent in seconds
+	TTL time.Duration
+	// ScheduleID is set by tipsd on a delayed message, it is the id returned when
+	// the message is published, the ID is assigned when the message is due
+	ScheduleID string
+}
+
+// size is the bytes a message takes in a publish request
+func (m *Message) size() int {
+	n := len(m.Payload) + len(m.DedupKey) + len(m.OrderingKey)
+	for k, v := range m.Attributes {
+		n += len(k) + len(v)
+	}
+	return n
+}
+
+// PullOptions are the options of a pull
+type PullOptions struct {
+	// Limit is the max messages pulled, 256 if it is 0
+	Limit int64
+	// Timeout is the max duration to wait for messages, it is truncated to seconds
+	// and a second is used if it is less than that
+	Timeout time.Duration
+	AutoACK bool
+	// Offset pulls the messages after it
+	Offset string
+	// AckDeadline is the duration before the messages are redelivered if they are not acked
+	AckDeadline time.Duration
+}
+
+// do sends a request and decodes the response into v if v is not nil
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.addr+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newError(resp.StatusCode, data)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+func escape(segments ...string) string {
+	var path string
+	for _, s := range segments {
+		path += "/" + url.PathEscape(s)
+	}
+	return path
+}
+
+// CreateTopic creates a topic, the retention policy is updated if it is not nil
+func (c *Client) CreateTopic(ctx context.Context, name string, retention *Retention) (*Topic, error) {
+	var body interface{}
+	if retention != nil {
+		body = map[string]interface{}{
+			"Retention": map[string]interface{}{
+				"MaxAge":   int64(retention.MaxAge / time.Second),
+				"MaxCount": retention.MaxCount,
+				"Acked":    retention.Acked,
+			},
+		}
+	}
+	t := &Topic{}
+	if err := c.do(ctx, http.MethodPut, "/v1/topics"+escape(name), body, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreatePartitionedTopic creates a topic whose messages are spread over the partitions,
+// an existing topic is returned only if it has the same partitions
+func (c *Client) CreatePartitionedTopic(ctx context.Context, name string, partitions int) (*Topic, error) {
+	body := map[string]interface{}{"Partitions": partitions}
+	t := &Topic{}
+	if err := c.do(ctx, http.MethodPut, "/v1/topics"+escape(name), body, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetTTL sets the time to live of the messages published to a topic without one, it
+// is truncated to seconds and 0 means they never expire. The topic is created if it
+// does not exist
+func (c *Client) SetTTL(ctx context.Context, name string, ttl time.Duration) (*Topic, error) {
+	body := map[string]interface{}{"TTL": int64(ttl / time.Second)}
+	t := &Topic{}
+	if err := c.do(ctx, http.MethodPut, "/v1/topics"+escape(name), body, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Topic returns a topic queried by name
+func (c *Client) Topic(ctx context.Context, name string) (*Topic, error) {
+	t := &Topic{}
+	if err := c.do(ctx, http.MethodGet, "/v1/topics"+escape(name), nil, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTopics lists a page of topics, the next page is listed with the returned
+// token, which is empty if there are no more topics
+func (c *Client) ListTopics(ctx context.Context, opts *ListOptions) ([]*Topic, string, error) {
+	page := &struct {
+		Topics    []*Topic
+		NextToken string
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/v1/topics"+opts.query(), nil, page); err != nil {
+		return nil, "", err
+	}
+	return page.Topics, page.NextToken, nil
+}
+
+// DeleteTopic deletes a topic
+func (c *Client) DeleteTopic(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/topics"+escape(name), nil, nil)
+}
+
+// Publish publishes messages to a topic, returns the message ids in the same order
+// The payloads are sent as strings if they are valid UTF-8, or encoded in base64 otherwise
+func (c *Client) Publish(ctx context.Context, topic string, msgs []*Message) ([]string, error) {
+	type message struct {
+		Payload       *string           `json:"payload,omitempty"`
+		PayloadBase64 []byte            `json:"payloadBase64,omitempty"`
+		Attributes    map[string]string `json:"attributes,omitempty"`
+		DedupKey      string            `json:"dedupKey,omitempty"`
+		OrderingKey   string            `json:"orderingKey,omitempty"`
+		DeliverAt     *time.Time        `json:"deliverAt,omitempty"`
+		Delay         int64             `json:"delay,omitempty"`
+		TTL           int64             `json:"ttl,omitempty"`
+	}
+	body := &struct {
+		Messages []*message
+	}{}
+	for _, msg := range msgs {
+		m := &message{
+			Attributes:  msg.Attributes,
+			DedupKey:    msg.DedupKey,
+			OrderingKey: msg.OrderingKey,
+			Delay:       int64(msg.Delay / time.Second),
+			TTL:         int64(msg.TTL / time.Second),
+		}
+		if utf8.Valid(msg.Payload) {
+			payload := string(msg.Payload)
+			m.Payload = &payload
+		} else {
+			// A []byte is encoded in base64 by encoding/json
+			m.PayloadBase64 = msg.Payload
+		}
+		if !msg.DeliverAt.IsZero() {
+			m.DeliverAt = &msg.DeliverAt
+		}
+		body.Messages = append(body.Messages, m)
+	}
+	var ids []string
+	if err := c.do(ctx, http.MethodPost, "/v1/messages/topics"+escape(topic), body, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Ack acknowledges a message
+func (c *Client) Ack(ctx context.Context, topic, subName, msgid string) error {
+	return c.do(ctx, http.MethodPost, "/v1/messages/ack"+escape(topic, subName, msgid), nil, nil)
+}
+
+// Nack makes a message redelivered on the next pull
+func (c *Client) Nack(ctx context.Context, topic, subName, msgid string) error {
+	return c.do(ctx, http.MethodPost, "/v1/messages/nack"+escape(topic, subName, msgid), nil, nil)
+}
+
+// ModifyAckDeadline resets the ack deadline of a pulled message, it is truncated to seconds
+func (c *Client) ModifyAckDeadline(ctx context.Context, topic, subName, msgid string, deadline time.Duration) error {
+	body := map[string]int64{"Deadline": int64(deadline / time.Second)}
+	return c.do(ctx, http.MethodPost, "/v1/messages/deadline"+escape(topic, subName, msgid), body, nil)
+}
+
+// Subscribe subscribes a topic, the options are updated if they are given
+func (c *Client) Subscribe(ctx context.Context, topic, subName string, opts *SubscribeOptions) (*Subscription, error) {
+	var body interface{}
+	if opts != nil {
+		if opts.ClearFilter && opts.Filter != "" {
+			return nil, errors.New("tips: filter should be empty to clear the filter")
+		}
+		if opts.ClearPushEndpoint && opts.PushEndpoint != "" {
+			return nil, errors.New("tips: push endpoint should be empty to clear the push endpoint")
+		}
+		// An explicit empty filter or push endpoint clears it
+		req := &struct {
+			*SubscribeOptions
+			Filter       *string `json:",omitempty"`
+			PushEndpoint *string `json:",omitempty"`
+		}{SubscribeOptions: opts}
+		if opts.Filter != "" || opts.ClearFilter {
+			req.Filter = &opts.Filter
+		}
+		if opts.PushEndpoint != "" || opts.ClearPushEndpoint {
+			req.PushEndpoint = &opts.PushEndpoint
+		}
+		body = req
+	}
+	sub := &Subscription{}
+	if err := c.do(ctx, http.MethodPut, "/v1/subscriptions"+escape(topic, subName), body, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe deletes a subscription
+func (c *Client) Unsubscribe(ctx context.Context, topic, subName string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/subscriptions"+escape(topic, subName), nil, nil)
+}
+
+// Subscription returns a subscription with its backlog
+func (c *Client) Subscription(ctx context.Context, topic, subName string) (*SubscriptionInfo, error) {
+	info := &SubscriptionInfo{}
+	if err := c.do(ctx, http.MethodGet, "/v1/subscriptions"+escape(topic, subName), nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListSubscriptions lists a page of the subscriptions of a topic like ListTopics
+func (c *Client) ListSubscriptions(ctx context.Context, topic string, opts *ListOptions) ([]*Subscription, string, error) {
+	page := &struct {
+		Subscriptions []*Subscription
+		NextToken     string
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/v1/subscriptions"+escape(topic)+opts.query(), nil, page); err != nil {
+		return nil, "", err
+	}
+	return page.Subscriptions, page.NextToken, nil
+}
+
+// Pull pulls the messages of a subscription, it waits until there are messages or the
+// timeout expires, an empty result is returned on timeout
+func (c *Client) Pull(ctx context.Context, topic, subName string, opts *PullOptions) ([]*Message, error) {
+	if opts == nil {
+		opts = &PullOptions{}
+	}
+	timeout := int64(opts.Timeout / time.Second)
+	if timeout <= 0 {
+		// tipsd waits for an hour if the timeout is 0
+		timeout = 1
+	}
+	body := map[string]interface{}{
+		"Limit":       opts.Limit,
+		"Timeout":     timeout,
+		"AutoACK":     opts.AutoACK,
+		"Offset":      opts.Offset,
+		"AckDeadline": int64(opts.AckDeadline / time.Second),
+	}
+	var msgs []*Message
+	if err := c.do(ctx, http.MethodPost, "/v1/subscriptions"+escape(topic, subName), body, &msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// CreateSnapshot creates a snapshot of a subscription
+func (c *Client) CreateSnapshot(ctx context.Context, topic, subName, name string) error {
+	return c.do(ctx, http.MethodPut, "/v1/snapshots"+escape(topic, subName, name), nil, nil)
+}
+
+// ListSnapshots lists a page of the snapshots of a subscription like ListTopics
+func (c *Client) ListSnapshots(ctx context.Context, topic, subName string, opts *ListOptions) ([]*Snapshot, string, error) {
+	page := &struct {
+		Snapshots []*Snapshot
+		NextToken string
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/v1/snapshots"+escape(topic, subName)+opts.query(), nil, page); err != nil {
+		return nil, "", err
+	}
+	return page.Snapshots, page.NextToken, nil
+}
+
+// DeleteSnapshot deletes a snapshot
+func (c *Client) DeleteSnapshot(ctx context.Context, topic, subName, name string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/snapshots"+escape(topic, subName, name), nil, nil)
+}
+
+// Seek resets a subscription to a snapshot
+func (c *Client) Seek(ctx context.Context, topic, subName, name string) (*Subscription, error) {
+	sub := &Subscription{}
+	if err := c.do(ctx, http.MethodPost, "/v1/snapshots"+escape(topic, subName, name), nil, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SeekToTime resets a subscription to a time, the messages published at or after
+// it are redelivered
+func (c *Client) SeekToTime(ctx context.Context, topic, subName string, t time.Time) (*Subscription, error) {
+	body := map[string]time.Time{"Time": t}
+	sub := &Subscription{}
+	if err := c.do(ctx, http.MethodPost, "/v1/subscriptions"+escape(topic, subName, "seek"), body, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SeekToOffset resets a subscription to an offset such as the id of a message,
+// the messages after it are redelivered
+func (c *Client) SeekToOffset(ctx context.Context, topic, subName, offset string) (*Subscription, error) {
+	body := map[string]string{"Offset": offset}
+	sub := &Subscription{}
+	if err := c.do(ctx, http.MethodPost, "/v1/subscriptions"+escape(topic, subName, "seek"), body, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTips is an in-memory tipsd serving the publishing and the pulling of subscription s1
+type fakeTips struct {
+	mu      sync.Mutex
+	batches [][]string
+	queue   []*Message
+	acked   []string
+	nacked  []string
+	next    int
+	// failures are the statuses responded to the next pulls
+	failures []int
+}
+
+func (f *fakeTips) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "messages" && parts[1] == "topics":
+		req := &struct {
+			Messages []struct {
+				Payload       string
+				PayloadBase64 []byte
+				Attributes    map[string]string
+			}
+		}{}
+		json.NewDecoder(r.Body).Decode(req)
+		var batch, ids []string
+		for _, m := range req.Messages {
+			f.next++
+			id := fmt.Sprint(f.next)
+			payload := []byte(m.Payload)
+			if m.PayloadBase64 != nil {
+				payload = m.PayloadBase64
+			}
+			batch = append(batch, string(payload))
+			ids = append(ids, id)
+			f.queue = append(f.queue, &Message{ID: id, Payload: payload, Attributes: m.Attributes})
+		}
+		f.batches = append(f.batches, batch)
+		json.NewEncoder(w).Encode(ids)
+	case len(parts) == 3 && parts[0] == "subscriptions" && parts[2] == "s1":
+		if len(f.failures) > 0 {
+			w.WriteHeader(f.failures[0])
+			f.failures = f.failures[1:]
+			return
+		}
+		req := &struct {
+			Limit int
+		}{}
+		json.NewDecoder(r.Body).Decode(req)
+		if len(f.queue) == 0 {
+			// Wait a while like a long polling
+			f.mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			f.mu.Lock()
+		}
+		n := req.Limit
+		if n > len(f.queue) {
+			n = len(f.queue)
+		}
+		msgs := f.queue[:n]
+		f.queue = f.queue[n:]
+		json.NewEncoder(w).Encode(msgs)
+	case len(parts) == 5 && parts[0] == "messages" && parts[1] == "ack":
+		f.acked = append(f.acked, parts[4])
+	case len(parts) == 5 && parts[0] == "messages" && parts[1] == "nack":
+		f.nacked = append(f.nacked, parts[4])
+		f.queue = append(f.queue, &Message{ID: parts[4], Payload: []byte(parts[4])})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"reason":"subname can not found"}`))
+	}
+}
+
+func TestError(t *testing.T) {
+	var status int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	c := NewClient(server.URL + "/")
+
+	for _, tc := range []struct {
+		status int
+		body   string
+		code   string
+		reason string
+	}{
+		{http.StatusNotFound, `{"code":"not_found","reason":"topic not found"}`, "not_found", "topic not found"},
+		{http.StatusNotFound, `{"reason":"topic can not found"}`, "", "topic can not found"},
+		{http.StatusBadRequest, `"invalid character"`, "", "invalid character"},
+		{http.StatusBadGateway, "bad gateway\n", "", "bad gateway"},
+		{http.StatusInternalServerError, "", "", http.StatusText(http.StatusInternalServerError)},
+		{http.StatusConflict, `{"code":"conflict","reason":"conflicted"}`, "conflict", "conflicted"},
+		{http.StatusTooManyRequests, "slow down\n", "", "slow down"},
+	} {
+		status, body = tc.status, tc.body
+		_, err := c.Topic(context.Background(), "t1")
+		require.Error(t, err)
+		e, ok := err.(*Error)
+		require.True(t, ok)
+		assert.Equal(t, tc.status, e.StatusCode)
+		assert.Equal(t, tc.code, e.Code)
+		assert.Equal(t, tc.reason, e.Reason)
+		assert.Equal(t, tc.status == http.StatusNotFound, IsNotFound(err))
+		assert.Equal(t, tc.status != http.StatusNotFound && tc.status != http.StatusBadRequest, IsRetryable(err))
+	}
+
+	// A malformed response is not retryable
+	status, body = http.StatusOK, "{"
+	_, err := c.Topic(context.Background(), "t1")
+	require.Error(t, err)
+	assert.False(t, IsRetryable(err))
+
+	// Neither is a canceled request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Topic(ctx, "t1")
+	require.Error(t, err)
+	assert.False(t, IsRetryable(err))
+
+	// The network failures are retryable
+	server.Close()
+	_, err = c.Topic(context.Background(), "t1")
+	require.Error(t, err)
+	assert.True(t, IsRetryable(err))
+}
+
+func TestPublishBinary(t *testing.T) {
+	fake := &fakeTips{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	binary := []byte{0xff, 0x00, 0xfe}
+	_, err := c.Publish(context.Background(), "t1", []*Message{{Payload: binary}, {Payload: []byte("text")}})
+	require.NoError(t, err)
+	msgs, err := c.Pull(context.Background(), "t1", "s1", &PullOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, binary, msgs[0].Payload)
+	assert.Equal(t, []byte("text"), msgs[1].Payload)
+}
+
+func TestPath(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+	}))
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	assert.NoError(t, c.Ack(context.Background(), "t1", "s 1", "id/1"))
+	assert.Equal(t, "/v1/messages/ack/t1/s%201/id%2F1", path)
+}
+
+func TestListOptions(t *testing.T) {
+	var opts *ListOptions
+	assert.Equal(t, "", opts.query())
+	assert.Equal(t, "", (&ListOptions{}).query())
+	assert.Equal(t, "?limit=10&prefix=a+b&token=dA", (&ListOptions{Prefix: "a b", Token: "dA", Limit: 10}).query())
+}
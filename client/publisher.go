@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPublisherStopped is returned when publishing with a stopped publisher
+var ErrPublisherStopped = errors.New("tips: publisher is stopped")
+
+// PublishSettings controls when the messages of a publisher are sent in a batch,
+// a batch is sent once any of the thresholds is reached
+type PublishSettings struct {
+	// CountThreshold is the max messages in a batch
+	CountThreshold int
+	// ByteThreshold is the max bytes of the payloads and attributes in a batch
+	ByteThreshold int
+	// DelayThreshold is the max duration a message waits before it is sent
+	DelayThreshold time.Duration
+	// Timeout is the timeout of a publish request
+	Timeout time.Duration
+}
+
+// DefaultPublishSettings is used if the settings of a publisher are not given
+var DefaultPublishSettings = PublishSettings{
+	CountThreshold: 100,
+	ByteThreshold:  1 << 20,
+	DelayThreshold: 10 * time.Millisecond,
+	Timeout:        60 * time.Second,
+}
+
+// PublishResult is the result of a message published by a publisher
+type PublishResult struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// Ready returns a channel which is closed once the message is published or failed
+func (r *PublishResult) Ready() <-chan struct{} {
+	return r.done
+}
+
+// Get waits until the message is published and returns its id
+func (r *PublishResult) Get(ctx context.Context) (string, error) {
+	select {
+	case <-r.done:
+		return r.id, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Publisher publishes messages to a topic in batches
+type Publisher struct {
+	c        *Client
+	topic    string
+	settings PublishSettings
+
+	mu      sync.Mutex
+	msgs    []*Message
+	results []*PublishResult
+	bytes   int
+	timer   *time.Timer
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// Publisher creates a publisher of a topic, DefaultPublishSettings is used if
+// settings is nil, and the zero fields are set to the default ones
+func (c *Client) Publisher(topic string, settings *PublishSettings) *Publisher {
+	s := DefaultPublishSettings
+	if settings != nil {
+		s = *settings
+		if s.CountThreshold <= 0 {
+			s.CountThreshold = DefaultPublishSettings.CountThreshold
+		}
+		if s.ByteThreshold <= 0 {
+			s.ByteThreshold = DefaultPublishSettings.ByteThreshold
+		}
+		if s.DelayThreshold <= 0 {
+			s.DelayThreshold = DefaultPublishSettings.DelayThreshold
+		}
+		if s.Timeout <= 0 {
+			s.Timeout = DefaultPublishSettings.Timeout
+		}
+	}
+	return &Publisher{c: c, topic: topic, settings: s}
+}
+
+// Publish adds a message to the current batch, the returned result is ready once
+// the batch is sent
+func (p *Publisher) Publish(msg *Message) *PublishResult {
+	r := &PublishResult{done: make(chan struct{})}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		r.err = ErrPublisherStopped
+		close(r.done)
+		return r
+	}
+
+	// A message is sent in the next batch if it overflows the current one
+	size := msg.size()
+	if len(p.msgs) > 0 && p.bytes+size > p.settings.ByteThreshold {
+		p.flushLocked()
+	}
+	p.msgs = append(p.msgs, msg)
+	p.results = append(p.results, r)
+	p.bytes += size
+	if len(p.msgs) >= p.settings.CountThreshold || p.bytes >= p.settings.ByteThreshold {
+		p.flushLocked()
+	} else if p.timer == nil {
+		p.timer = time.AfterFunc(p.settings.DelayThreshold, p.Flush)
+	}
+	return r
+}
+
+// Flush sends the current batch without waiting for the thresholds
+func (p *Publisher) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushLocked()
+}
+
+// Stop sends the current batch and waits until all the batches are done, the
+// messages published after it fail with ErrPublisherStopped
+func (p *Publisher) Stop() {
+	p.mu.Lock()
+	p.stopped = true
+	p.flushLocked()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// flushLocked sends the current batch in the background, p.mu should be held
+func (p *Publisher) flushLocked() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if len(p.msgs) == 0 {
+		return
+	}
+	msgs, results := p.msgs, p.results
+	p.msgs, p.results, p.bytes = nil, nil, 0
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), p.settings.Timeout)
+		defer cancel()
+		ids, err := p.c.Publish(ctx, p.topic, msgs)
+		if err == nil && len(ids) != len(msgs) {
+			err = errors.New("tips: the number of message ids mismatches")
+		}
+		for i, r := range results {
+			if err != nil {
+				r.err = err
+			} else {
+				r.id = ids[i]
+			}
+			close(r.done)
+		}
+	}()
+}
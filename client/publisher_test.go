@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisher(t *testing.T) {
+	fake := &fakeTips{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	p := c.Publisher("t1", &PublishSettings{CountThreshold: 3, ByteThreshold: 10, DelayThreshold: 20 * time.Millisecond})
+	var results []*PublishResult
+	// Batched by count
+	for _, payload := range []string{"a", "b", "c", "d"} {
+		results = append(results, p.Publish(&Message{Payload: []byte(payload)}))
+	}
+	// Batched by latency
+	select {
+	case <-results[3].Ready():
+	case <-time.After(time.Second):
+		t.Fatal("message is not sent after the delay threshold")
+	}
+	// Batched by bytes, "fghijklmno" overflows the batch of "e"
+	for _, payload := range []string{"e", "fghijklmno"} {
+		results = append(results, p.Publish(&Message{Payload: []byte(payload)}))
+	}
+	p.Stop()
+
+	for i, r := range results {
+		id, err := r.Get(context.Background())
+		require.NoError(t, err)
+		assert.NotEmpty(t, id, "message %d", i)
+	}
+	fake.mu.Lock()
+	// Batches are sent concurrently
+	sort.Slice(fake.batches, func(i, j int) bool { return fake.batches[i][0] < fake.batches[j][0] })
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}, {"e"}, {"fghijklmno"}}, fake.batches)
+	fake.mu.Unlock()
+
+	_, err := p.Publish(&Message{Payload: []byte("p")}).Get(context.Background())
+	assert.Equal(t, ErrPublisherStopped, err)
+}
+
+func TestPublisherError(t *testing.T) {
+	server := httptest.NewServer(&fakeTips{})
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	p := c.Publisher("t1", nil)
+	r := p.Publish(&Message{Payload: []byte("a")})
+	p.Flush()
+	_, err := r.Get(context.Background())
+	assert.NoError(t, err)
+
+	// The publisher is unavailable
+	server.Close()
+	r = p.Publish(&Message{Payload: []byte("b")})
+	p.Stop()
+	_, err = r.Get(context.Background())
+	assert.Error(t, err)
+}
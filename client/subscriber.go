@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReceiveSettings controls how the messages of a subscriber are pulled and handled
+type ReceiveSettings struct {
+	// MaxOutstanding is the max messages handled concurrently
+	MaxOutstanding int
+	// AckDeadline is the duration a message is leased to the subscriber, it
+	// should be long enough to handle a message
+	AckDeadline time.Duration
+	// PullTimeout is the max duration a pull waits for messages
+	PullTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the exponential backoff after a failed pull
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultReceiveSettings is used if the settings of a subscriber are not given
+var DefaultReceiveSettings = ReceiveSettings{
+	MaxOutstanding: 10,
+	AckDeadline:    time.Minute,
+	PullTimeout:    30 * time.Second,
+	MinBackoff:     100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// Subscriber receives the messages of a subscription
+type Subscriber struct {
+	c        *Client
+	topic    string
+	subName  string
+	settings ReceiveSettings
+}
+
+// Subscriber creates a subscriber of a subscription, DefaultReceiveSettings is used
+// if settings is nil, and the zero fields are set to the default ones
+func (c *Client) Subscriber(topic, subName string, settings *ReceiveSettings) *Subscriber {
+	s := DefaultReceiveSettings
+	if settings != nil {
+		s = *settings
+		if s.MaxOutstanding <= 0 {
+			s.MaxOutstanding = DefaultReceiveSettings.MaxOutstanding
+		}
+		if s.AckDeadline <= 0 {
+			s.AckDeadline = DefaultReceiveSettings.AckDeadline
+		}
+		if s.PullTimeout <= 0 {
+			s.PullTimeout = DefaultReceiveSettings.PullTimeout
+		}
+		if s.MinBackoff <= 0 {
+			s.MinBackoff = DefaultReceiveSettings.MinBackoff
+		}
+		if s.MaxBackoff < s.MinBackoff {
+			s.MaxBackoff = s.MinBackoff
+		}
+	}
+	return &Subscriber{c: c, topic: topic, subName: subName, settings: s}
+}
+
+// Receive pulls messages and calls f concurrently until the ctx is done, a message
+// is acked if f returns nil, otherwise it is nacked and redelivered. At most
+// MaxOutstanding messages are handled at the same time.
+//
+// Receive returns nil once the ctx is done and all the calls of f have returned.
+// Failed pulls are retried with backoff, except the errors which are not retryable
+// such as a missing subscription, which are returned. See IsRetryable.
+func (s *Subscriber) Receive(ctx context.Context, f func(ctx context.Context, msg *Message) error) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// Each message handled takes a token
+	tokens := make(chan struct{}, s.settings.MaxOutstanding)
+	var backoff time.Duration
+	for {
+		// Wait for a free slot at least, and take all the free ones
+		select {
+		case <-ctx.Done():
+			return nil
+		case tokens <- struct{}{}:
+		}
+		limit := 1
+	take:
+		for limit < s.settings.MaxOutstanding {
+			select {
+			case tokens <- struct{}{}:
+				limit++
+			default:
+				break take
+			}
+		}
+
+		msgs, err := s.c.Pull(ctx, s.topic, s.subName, &PullOptions{
+			Limit:       int64(limit),
+			Timeout:     s.settings.PullTimeout,
+			AckDeadline: s.settings.AckDeadline,
+		})
+		// Give back the slots which are not used
+		for i := len(msgs); i < limit; i++ {
+			<-tokens
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !IsRetryable(err) {
+				return err
+			}
+			if backoff == 0 {
+				backoff = s.settings.MinBackoff
+			} else if backoff *= 2; backoff > s.settings.MaxBackoff {
+				backoff = s.settings.MaxBackoff
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		for _, msg := range msgs {
+			wg.Add(1)
+			go func(msg *Message) {
+				defer wg.Done()
+				defer func() { <-tokens }()
+				s.handle(ctx, msg, f)
+			}(msg)
+		}
+	}
+}
+
+// handle calls f and acks or nacks the message, failures of acking are ignored
+// because the message is redelivered after the ack deadline anyway
+func (s *Subscriber) handle(ctx context.Context, msg *Message, f func(ctx context.Context, msg *Message) error) {
+	err := f(ctx, msg)
+	// Acks are sent even if the ctx is done, so that the handled messages are not redelivered
+	actx, cancel := context.WithTimeout(context.Background(), s.settings.PullTimeout)
+	defer cancel()
+	if err != nil {
+		s.c.Nack(actx, s.topic, s.subName, msg.ID)
+		return
+	}
+	s.c.Ack(actx, s.topic, s.subName, msg.ID)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceive(t *testing.T) {
+	fake := &fakeTips{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	var msgs []*Message
+	for i := 0; i < 20; i++ {
+		msgs = append(msgs, &Message{Payload: []byte(fmt.Sprint(i))})
+	}
+	_, err := c.Publish(context.Background(), "t1", msgs)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var running, maxRunning, handled int
+	failed := make(map[string]bool)
+	s := c.Subscriber("t1", "s1", &ReceiveSettings{MaxOutstanding: 4})
+	err = s.Receive(ctx, func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		// The first message fails once and is redelivered
+		if msg.ID == "1" && !failed[msg.ID] {
+			failed[msg.ID] = true
+			return errors.New("failed")
+		}
+		if handled++; handled == 20 {
+			cancel()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.True(t, maxRunning <= 4, "%d messages are handled concurrently", maxRunning)
+	assert.Equal(t, []string{"1"}, fake.nacked)
+	sort.Strings(fake.acked)
+	var ids []string
+	for i := 1; i <= 20; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	sort.Strings(ids)
+	assert.Equal(t, ids, fake.acked)
+}
+
+func TestReceiveNotFound(t *testing.T) {
+	server := httptest.NewServer(&fakeTips{})
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	s := c.Subscriber("t1", "s2", nil)
+	err := s.Receive(context.Background(), func(ctx context.Context, msg *Message) error {
+		return nil
+	})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestReceiveRetry(t *testing.T) {
+	// Conflicts and throttling are retried rather than returned
+	fake := &fakeTips{failures: []int{http.StatusConflict, http.StatusTooManyRequests}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	c := NewClient(server.URL)
+	_, err := c.Publish(context.Background(), "t1", []*Message{{Payload: []byte("a")}})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s := c.Subscriber("t1", "s1", &ReceiveSettings{MinBackoff: time.Millisecond})
+	err = s.Receive(ctx, func(ctx context.Context, msg *Message) error {
+		cancel()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Empty(t, fake.failures)
+	assert.Equal(t, []string{"1"}, fake.acked)
+}
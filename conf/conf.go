@@ -1,18 +1,65 @@
 package conf
 
+import "time"
+
 type Tips struct {
 	Server      Server     `cfg:"server"`
 	Status      Status     `cfg:"status"`
 	TikvLog     TikvLogger `cfg:"tikv-logger"`
 	Logger      Logger     `cfg:"logger"`
+	GC          GC         `cfg:"gc"`
+	Trimmer     Trimmer    `cfg:"trimmer"`
+	Scheduler   Scheduler  `cfg:"scheduler"`
+	Push        Push       `cfg:"push"`
+	Notifier    Notifier   `cfg:"notifier"`
+	Dedup       Dedup      `cfg:"dedup"`
 	PIDFileName string     `cfg:"pid-filename; tips.pid; ; the file name to record connd PID"`
 }
 
 type Server struct {
-	Tikv   Tikv   `cfg:"tikv"`
-	Listen string `cfg:"listen; 0.0.0.0:7369; netaddr; address to listen"`
-	Key    string `cfg:"key;;; key file name"`
-	Cert   string `cfg:"Cert;;; tls session ticket file name. ticket use: openssl rand 32"`
+	Tikv       Tikv   `cfg:"tikv"`
+	Listen     string `cfg:"listen; 0.0.0.0:7369; netaddr; address to listen"`
+	GRPCListen string `cfg:"grpc-listen; 0.0.0.0:7370; netaddr; address to listen for the gRPC API"`
+	Key        string `cfg:"key;;; key file name"`
+	Cert       string `cfg:"Cert;;; tls session ticket file name. ticket use: openssl rand 32"`
+}
+
+type GC struct {
+	Enable    bool          `cfg:"enable; true; boolean; enable the background gc of deleted topics"`
+	Interval  time.Duration `cfg:"interval; 10s; ; the interval between two gc rounds"`
+	BatchSize int           `cfg:"batch-size; 256; numeric >0; max keys deleted in a transaction"`
+}
+
+type Trimmer struct {
+	Enable    bool          `cfg:"enable; true; boolean; enable trimming messages out of the retention of topics and the expired ones"`
+	Interval  time.Duration `cfg:"interval; 10s; ; the interval between two trim rounds"`
+	BatchSize int           `cfg:"batch-size; 256; numeric >0; max messages deleted in a transaction"`
+}
+
+type Scheduler struct {
+	Enable    bool          `cfg:"enable; true; boolean; enable delivering the delayed messages which are due"`
+	Interval  time.Duration `cfg:"interval; 1s; ; the interval to check the delayed messages"`
+	BatchSize int           `cfg:"batch-size; 256; numeric >0; max messages delivered in a transaction"`
+}
+
+type Push struct {
+	Enable       bool          `cfg:"enable; true; boolean; enable delivering messages to the endpoints of push subscriptions"`
+	Interval     time.Duration `cfg:"interval; 10s; ; the interval to discover push subscriptions"`
+	PollInterval time.Duration `cfg:"poll-interval; 1s; ; the max interval to pull a subscription which has no message"`
+	BatchSize    int           `cfg:"batch-size; 32; numeric; max messages posted in a request"`
+	Timeout      time.Duration `cfg:"timeout; 10s; ; the timeout of a request to the endpoint"`
+	MinBackoff   time.Duration `cfg:"min-backoff; 100ms; ; the backoff after the first failed request"`
+	MaxBackoff   time.Duration `cfg:"max-backoff; 60s; ; the max backoff between retries"`
+}
+
+type Notifier struct {
+	Enable   bool          `cfg:"enable; true; boolean; enable notifying the messages published on other nodes"`
+	Interval time.Duration `cfg:"interval; 100ms; ; the interval to check the topics published on other nodes"`
+	NodeID   string        `cfg:"node-id;;; the id of this node, stable across restarts and unique among nodes, the hostname and the listen address if it is empty"`
+}
+
+type Dedup struct {
+	Window time.Duration `cfg:"window; 10m; ; messages published with the same dedup key within the window are written only once, 0 disables it"`
 }
 
 type Tikv struct {
@@ -36,5 +83,9 @@ type TikvLogger struct {
 
 //TODO
 type Status struct {
-	Listen string `cfg:"listen;0.0.0.0:7345;nonempty; listen address of http server"`
+	Listen           string        `cfg:"listen;0.0.0.0:7345;nonempty; listen address of http server"`
+	StatsInterval    time.Duration `cfg:"stats-interval; 30s; ; the interval to collect the gauges of topics and subscriptions, 0 disables it"`
+	MaxTopics        int           `cfg:"max-topics; 100; numeric; max topics exported by the gauges, in the order of names"`
+	MaxSubscriptions int           `cfg:"max-subscriptions; 1000; numeric; max subscriptions exported by the gauges"`
+	MaxScan          int           `cfg:"max-scan; 10000; numeric; max messages scanned to count a topic or a subscription"`
 }
@@ -0,0 +1,87 @@
+package tips
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tipsio/tips/store/pubsub"
+)
+
+// Code classifies the errors returned by Tips, so that the servers map them to
+// their status codes without matching the messages
+type Code int
+
+const (
+	// Internal is an unexpected error, such as a corrupted value
+	Internal Code = iota
+	// NotFound is returned if a topic, subscription, snapshot or message is missing
+	NotFound
+	// AlreadyExists is returned if a key to be inserted has been written
+	AlreadyExists
+	// Conflict is returned if a transaction conflicts with a concurrent one, it is safe to retry
+	Conflict
+	// InvalidArgument is returned if a request is malformed
+	InvalidArgument
+	// Unavailable is returned if the storage can not be reached in time
+	Unavailable
+)
+
+var codeNames = [...]string{
+	Internal:        "internal",
+	NotFound:        "not_found",
+	AlreadyExists:   "already_exists",
+	Conflict:        "conflict",
+	InvalidArgument: "invalid_argument",
+	Unavailable:     "unavailable",
+}
+
+func (c Code) String() string {
+	if c < 0 || int(c) >= len(codeNames) {
+		return codeNames[Internal]
+	}
+	return codeNames[c]
+}
+
+// Error is an error with a code
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// notFound returns the error that a topic, subscription, snapshot or message is missing
+func notFound(what string) error {
+	return &Error{Code: NotFound, Message: what + " not found"}
+}
+
+// invalidArgument returns the error that a request is malformed
+func invalidArgument(format string, args ...interface{}) error {
+	return &Error{Code: InvalidArgument, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode returns the code of an error returned by Tips, the errors of the
+// storage are classified by their causes
+func ErrorCode(err error) Code {
+	if e, ok := err.(*Error); ok {
+		return e.Code
+	}
+	switch {
+	case err == pubsub.ErrNotFound:
+		return NotFound
+	case err == pubsub.ErrInvalidOffset:
+		return InvalidArgument
+	case err == context.DeadlineExceeded:
+		return Unavailable
+	// The timeouts of TiKV are retryable as well, so they are checked first
+	case pubsub.IsUnavailable(err):
+		return Unavailable
+	case pubsub.IsRetryableError(err):
+		return Conflict
+	case pubsub.IsAlreadyExists(err):
+		return AlreadyExists
+	}
+	return Internal
+}
@@ -0,0 +1,64 @@
+package tips
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/stretchr/testify/assert"
+	"github.com/tipsio/tips/store/pubsub"
+)
+
+func TestErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code Code
+	}{
+		{notFound("topic"), NotFound},
+		{ErrInvalidToken, InvalidArgument},
+		{invalidArgument("bad %s", "filter"), InvalidArgument},
+		{pubsub.ErrNotFound, NotFound},
+		{pubsub.ErrInvalidOffset, InvalidArgument},
+		{context.DeadlineExceeded, Unavailable},
+		{tikv.ErrTiKVServerTimeout, Unavailable},
+		{tikv.ErrRegionUnavailable, Unavailable},
+		{kv.ErrRetryable, Conflict},
+		{kv.ErrKeyExists, AlreadyExists},
+		{pubsub.ErrCorrupted, Internal},
+		{errors.New("unknown"), Internal},
+	} {
+		assert.Equal(t, tc.code, ErrorCode(tc.err), tc.err.Error())
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	assert.Equal(t, "not_found", NotFound.String())
+	assert.Equal(t, "invalid_argument", InvalidArgument.String())
+	assert.Equal(t, "internal", Code(-1).String())
+	assert.Equal(t, "internal", Code(100).String())
+}
+
+func TestInvalidArgument(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = tips.SetFilter(ctx, "s1", "t1", "attributes.a = ")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.SetDeadLetter(ctx, "s1", "t1", "t1", 1)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.SetPushEndpoint(ctx, "s1", "t1", "ftp://localhost")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.Subscribe(ctx, "s2", "t1", &StartPosition{Earliest: true, Offset: &pubsub.Offset{}})
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	err = tips.Ack(ctx, "garbage", "t1", "s1")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+}
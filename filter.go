@@ -0,0 +1,354 @@
+package tips
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/* Filter selects messages of a subscription by their attributes
+*  attributes:key                       // the key exists
+*  attributes.key = "value"             // the value equals
+*  attributes.key != "value"            // the value does not equal or the key does not exist
+*  hasPrefix(attributes.key, "prefix")  // the value has the prefix
+*
+*  Conditions can be combined with NOT, AND, OR and parentheses, NOT binds
+*  tighter than AND which binds tighter than OR.
+ */
+
+// Filter is a parsed filter expression
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// ParseFilter parses a filter expression, an empty expression matches all messages
+func ParseFilter(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match returns true if the attributes match the filter, a nil filter matches all
+func (f *Filter) Match(attributes map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(attributes)
+}
+
+// String returns the expression of the filter
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+type filterNode interface {
+	match(attributes map[string]string) bool
+}
+
+type hasNode struct{ key string }
+
+func (n *hasNode) match(attributes map[string]string) bool {
+	_, ok := attributes[n.key]
+	return ok
+}
+
+type equalNode struct{ key, value string }
+
+func (n *equalNode) match(attributes map[string]string) bool {
+	v, ok := attributes[n.key]
+	return ok && v == n.value
+}
+
+type prefixNode struct{ key, prefix string }
+
+func (n *prefixNode) match(attributes map[string]string) bool {
+	v, ok := attributes[n.key]
+	return ok && strings.HasPrefix(v, n.prefix)
+}
+
+type notNode struct{ node filterNode }
+
+func (n *notNode) match(attributes map[string]string) bool {
+	return !n.node.match(attributes)
+}
+
+type andNode struct{ nodes []filterNode }
+
+func (n *andNode) match(attributes map[string]string) bool {
+	for _, node := range n.nodes {
+		if !node.match(attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode struct{ nodes []filterNode }
+
+func (n *orNode) match(attributes map[string]string) bool {
+	for _, node := range n.nodes {
+		if node.match(attributes) {
+			return true
+		}
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits the expression into tokens, the text of a string token is unquoted
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',' || c == '.' || c == ':' || c == '=':
+			tokens = append(tokens, token{tokenPunct, string(c), i})
+			i++
+		case c == '!' && i+1 < len(expr) && expr[i+1] == '=':
+			tokens = append(tokens, token{tokenPunct, "!=", i})
+			i += 2
+		case c == '"':
+			j := i + 1
+			for ; j < len(expr) && expr[j] != '"'; j++ {
+				if expr[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("invalid filter at %d: unterminated string", i)
+			}
+			s, err := strconv.Unquote(expr[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter at %d: %s", i, err)
+			}
+			tokens = append(tokens, token{tokenString, s, i})
+			i = j + 1
+		case isIdent(c):
+			j := i
+			for j < len(expr) && isIdent(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, expr[i:j], i})
+			i = j
+		default:
+			return nil, fmt.Errorf("invalid filter at %d: unexpected %q", i, c)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(expr)}), nil
+}
+
+func isIdent(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// filterParser is a recursive descent parser of filter expressions
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok token, format string, args ...interface{}) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("invalid filter at %d: unexpected end", tok.pos)
+	}
+	return fmt.Errorf("invalid filter at %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind || (text != "" && tok.text != text) {
+		if text == "" {
+			text = "a string"
+		}
+		return tok, p.errorf(tok, "expect %s but got %q", text, tok.text)
+	}
+	return tok, nil
+}
+
+func (p *filterParser) keyword(text string) bool {
+	tok := p.peek()
+	if tok.kind == tokenIdent && tok.text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) or() (filterNode, error) {
+	node, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []filterNode{node}
+	for p.keyword("OR") {
+		node, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &orNode{nodes: nodes}, nil
+}
+
+func (p *filterParser) and() (filterNode, error) {
+	node, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []filterNode{node}
+	for p.keyword("AND") {
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &andNode{nodes: nodes}, nil
+}
+
+func (p *filterParser) unary() (filterNode, error) {
+	if p.keyword("NOT") {
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: node}, nil
+	}
+	return p.primary()
+}
+
+func (p *filterParser) primary() (filterNode, error) {
+	tok := p.peek()
+	if tok.kind == tokenPunct && tok.text == "(" {
+		p.next()
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
+	if p.keyword("hasPrefix") {
+		if _, err := p.expect(tokenPunct, "("); err != nil {
+			return nil, err
+		}
+		key, err := p.attribute(".")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ","); err != nil {
+			return nil, err
+		}
+		prefix, err := p.expect(tokenString, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return &prefixNode{key: key, prefix: prefix.text}, nil
+	}
+
+	if _, err := p.expect(tokenIdent, "attributes"); err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if op.kind != tokenPunct || (op.text != "." && op.text != ":") {
+		return nil, p.errorf(op, "expect . or : but got %q", op.text)
+	}
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	if op.text == ":" {
+		return &hasNode{key: key}, nil
+	}
+
+	cmp := p.next()
+	if cmp.kind != tokenPunct || (cmp.text != "=" && cmp.text != "!=") {
+		return nil, p.errorf(cmp, "expect = or != but got %q", cmp.text)
+	}
+	value, err := p.expect(tokenString, "")
+	if err != nil {
+		return nil, err
+	}
+	var node filterNode = &equalNode{key: key, value: value.text}
+	if cmp.text == "!=" {
+		node = &notNode{node: node}
+	}
+	return node, nil
+}
+
+// attribute parses attributes{sep}key and returns the key
+func (p *filterParser) attribute(sep string) (string, error) {
+	if _, err := p.expect(tokenIdent, "attributes"); err != nil {
+		return "", err
+	}
+	if _, err := p.expect(tokenPunct, sep); err != nil {
+		return "", err
+	}
+	return p.key()
+}
+
+// key parses an attribute key, which is an identifier or a quoted string
+func (p *filterParser) key() (string, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent && tok.kind != tokenString {
+		return "", p.errorf(tok, "expect an attribute key but got %q", tok.text)
+	}
+	return tok.text, nil
+}
@@ -0,0 +1,67 @@
+package tips
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.Match(nil))
+
+	for _, expr := range []string{
+		"attributes",
+		"attributes.k",
+		"attributes.k =",
+		"attributes.k = v",
+		`attributes.k == "v"`,
+		`attributes:k AND`,
+		`(attributes:k`,
+		`attributes:k)`,
+		`hasPrefix(attributes:k, "v")`,
+		`hasPrefix(attributes.k "v")`,
+		`attributes.k = "v`,
+		`attributes.k = "v" OR NOT`,
+		`attributes:k & attributes:v`,
+	} {
+		_, err := ParseFilter(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	attributes := map[string]string{"type": "order", "region": "us-east-1", "trace id": `"quoted"`}
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{`attributes:type`, true},
+		{`attributes:color`, false},
+		{`attributes.type = "order"`, true},
+		{`attributes.type = "refund"`, false},
+		{`attributes.type != "refund"`, true},
+		{`attributes.color != "red"`, true},
+		{`attributes."trace id" = "\"quoted\""`, true},
+		{`hasPrefix(attributes.region, "us-")`, true},
+		{`hasPrefix(attributes.region, "eu-")`, false},
+		{`hasPrefix(attributes.color, "")`, false},
+		{`NOT attributes:color`, true},
+		{`NOT NOT attributes:color`, false},
+		{`attributes:type AND attributes:color`, false},
+		{`attributes:type OR attributes:color`, true},
+		// AND binds tighter than OR
+		{`attributes:color AND attributes:type OR attributes:region`, true},
+		{`attributes:color AND (attributes:type OR attributes:region)`, false},
+		{`NOT (attributes:color OR hasPrefix(attributes.region, "eu-")) AND attributes.type="order"`, true},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expr)
+		if assert.NoError(t, err, c.expr) {
+			assert.Equal(t, c.match, f.Match(attributes), c.expr)
+			assert.Equal(t, c.expr, f.String())
+		}
+	}
+}
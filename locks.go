@@ -0,0 +1,57 @@
+package tips
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tipsio/tips/store/pubsub"
+)
+
+const (
+	// maxRetries is the max times to retry a conflicted transaction
+	maxRetries = 16
+	// maxBackoff is the max duration to sleep before retrying
+	maxBackoff = 100 * time.Millisecond
+)
+
+// subscriptionLocks is a per-node mutex of subscriptions. It serializes the operations
+// which update the leases and the state of a subscription in a process, so that the
+// consumers of a subscription connected to the same node never conflict with each
+// other. It does not assign leases across nodes, the consumers on different nodes
+// still conflict in TiKV and the conflicted transactions are retried.
+// Every path which mutates the leases of a subscription should hold the lock.
+type subscriptionLocks [256]sync.Mutex
+
+// get returns the lock of a subscription
+func (l *subscriptionLocks) get(topic, subName string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(topic))
+	h.Write([]byte{':'})
+	h.Write([]byte(subName))
+	return &l[h.Sum32()%uint32(len(l))]
+}
+
+// retry calls f until it succeeds or fails with an error which is not caused by
+// transaction conflicts, it sleeps an exponential backoff with jitter between retries
+func (ti *Tips) retry(ctx context.Context, f func() error) error {
+	var err error
+	for i := uint(0); i < maxRetries; i++ {
+		if err = f(); err == nil || !pubsub.IsRetryableError(err) {
+			return err
+		}
+
+		backoff := time.Millisecond << i
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(rand.Int63n(int64(backoff)) + 1)):
+		}
+	}
+	return err
+}
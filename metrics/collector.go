@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tipsio/tips/conf"
+)
+
+// TopicStats is the size of a topic and the backlog of its subscriptions
+type TopicStats struct {
+	Topic    string
+	Messages int64
+	Bytes    int64
+
+	Subscriptions []*SubscriptionStats
+}
+
+// SubscriptionStats is the backlog of a subscription
+type SubscriptionStats struct {
+	Name             string
+	Backlog          int64
+	OldestUnackedAge time.Duration
+}
+
+// StatsFunc returns the stats of at most maxTopics topics in the order of names and
+// at most maxSubscriptions of their subscriptions in total, at most maxScan messages
+// are scanned to count a topic or a subscription
+type StatsFunc func(ctx context.Context, maxTopics int, maxSubscriptions int, maxScan int) ([]*TopicStats, error)
+
+// Collector exports the stats of topics and subscriptions as gauges periodically,
+// the number of series is limited by MaxTopics and MaxSubscriptions
+type Collector struct {
+	interval         time.Duration
+	maxTopics        int
+	maxSubscriptions int
+	maxScan          int
+	stats            StatsFunc
+
+	// topics and subscriptions are the label values exported by the last collection
+	topics        map[string]bool
+	subscriptions map[[2]string]bool
+}
+
+// NewCollector creates a collector which gets the stats by the stats function
+func NewCollector(config *conf.Status, stats StatsFunc) *Collector {
+	return &Collector{
+		interval:         config.StatsInterval,
+		maxTopics:        config.MaxTopics,
+		maxSubscriptions: config.MaxSubscriptions,
+		maxScan:          config.MaxScan,
+		stats:            stats,
+		topics:           make(map[string]bool),
+		subscriptions:    make(map[[2]string]bool),
+	}
+}
+
+// Run collects the stats every interval until the ctx is done
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.Collect(ctx); err != nil {
+			zap.L().Error("collect stats failed", zap.Error(err))
+		}
+	}
+}
+
+// Collect gets the stats and replaces the gauges with them, the subscriptions
+// beyond MaxSubscriptions are not exported. It is not safe to be called concurrently
+func (c *Collector) Collect(ctx context.Context) error {
+	stats, err := c.stats(ctx, c.maxTopics, c.maxSubscriptions, c.maxScan)
+	if err != nil {
+		return err
+	}
+
+	topics := make(map[string]bool)
+	subscriptions := make(map[[2]string]bool)
+	for i, t := range stats {
+		if i >= c.maxTopics {
+			break
+		}
+		topics[t.Topic] = true
+		gm.TopicMessagesGaugeVec.WithLabelValues(t.Topic).Set(float64(t.Messages))
+		gm.TopicBytesGaugeVec.WithLabelValues(t.Topic).Set(float64(t.Bytes))
+		for _, s := range t.Subscriptions {
+			if len(subscriptions) >= c.maxSubscriptions {
+				break
+			}
+			subscriptions[[2]string{t.Topic, s.Name}] = true
+			gm.SubscriptionBacklogGaugeVec.WithLabelValues(t.Topic, s.Name).Set(float64(s.Backlog))
+			gm.SubscriptionOldestUnackedGaugeVec.WithLabelValues(t.Topic, s.Name).Set(s.OldestUnackedAge.Seconds())
+		}
+	}
+
+	// Delete the series of the topics and subscriptions which are deleted or out of the
+	// limits now, the others are kept so that they do not disappear between collections
+	for topic := range c.topics {
+		if !topics[topic] {
+			gm.TopicMessagesGaugeVec.DeleteLabelValues(topic)
+			gm.TopicBytesGaugeVec.DeleteLabelValues(topic)
+		}
+	}
+	for sub := range c.subscriptions {
+		if !subscriptions[sub] {
+			gm.SubscriptionBacklogGaugeVec.DeleteLabelValues(sub[0], sub[1])
+			gm.SubscriptionOldestUnackedGaugeVec.DeleteLabelValues(sub[0], sub[1])
+		}
+	}
+	c.topics, c.subscriptions = topics, subscriptions
+	return nil
+}
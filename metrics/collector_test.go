@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/tipsio/tips/conf"
+)
+
+func gaugeValue(t *testing.T, g interface {
+	Write(*dto.Metric) error
+}) float64 {
+	m := &dto.Metric{}
+	assert.NoError(t, g.Write(m))
+	return m.GetGauge().GetValue()
+}
+
+func TestCollector(t *testing.T) {
+	stats := []*TopicStats{
+		{Topic: "t1", Messages: 10, Bytes: 100, Subscriptions: []*SubscriptionStats{
+			{Name: "s1", Backlog: 3, OldestUnackedAge: 2 * time.Second},
+			{Name: "s2", Backlog: 5},
+		}},
+		{Topic: "t2", Messages: 1, Bytes: 8},
+	}
+	var maxTopics, maxSubscriptions, maxScan int
+	c := NewCollector(&conf.Status{MaxTopics: 1, MaxSubscriptions: 1, MaxScan: 64}, func(ctx context.Context, topics int, subs int, scan int) ([]*TopicStats, error) {
+		maxTopics, maxSubscriptions, maxScan = topics, subs, scan
+		return stats, nil
+	})
+	assert.NoError(t, c.Collect(context.Background()))
+	assert.Equal(t, 1, maxTopics)
+	assert.Equal(t, 1, maxSubscriptions)
+	assert.Equal(t, 64, maxScan)
+	assert.Equal(t, float64(10), gaugeValue(t, gm.TopicMessagesGaugeVec.WithLabelValues("t1")))
+	assert.Equal(t, float64(100), gaugeValue(t, gm.TopicBytesGaugeVec.WithLabelValues("t1")))
+	assert.Equal(t, float64(3), gaugeValue(t, gm.SubscriptionBacklogGaugeVec.WithLabelValues("t1", "s1")))
+	assert.Equal(t, float64(2), gaugeValue(t, gm.SubscriptionOldestUnackedGaugeVec.WithLabelValues("t1", "s1")))
+	// The series beyond the limits are not exported
+	assert.False(t, gm.SubscriptionBacklogGaugeVec.DeleteLabelValues("t1", "s2"))
+	assert.False(t, gm.TopicMessagesGaugeVec.DeleteLabelValues("t2"))
+
+	// The series not collected by this collector are kept
+	gm.TopicMessagesGaugeVec.WithLabelValues("other").Set(1)
+	// The series of the deleted topics are removed
+	stats = nil
+	assert.NoError(t, c.Collect(context.Background()))
+	assert.False(t, gm.TopicMessagesGaugeVec.DeleteLabelValues("t1"))
+	assert.False(t, gm.SubscriptionBacklogGaugeVec.DeleteLabelValues("t1", "s1"))
+	assert.True(t, gm.TopicMessagesGaugeVec.DeleteLabelValues("other"))
+
+	c = NewCollector(&conf.Status{}, func(ctx context.Context, topics int, subs int, scan int) ([]*TopicStats, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Error(t, c.Collect(context.Background()))
+}
@@ -17,12 +17,18 @@ const (
 	leader    = "leader"
 	labelName = "level"
 	gckeys    = "gckeys"
+	topic     = "topic"
+	sub       = "subscription"
+	result    = "result"
 )
 
 var (
 	optLabel    = []string{opt}
 	leaderLabel = []string{leader}
 	gcKeysLabel = []string{gckeys}
+	topicLabel  = []string{topic}
+	subLabel    = []string{topic, sub}
+	resultLabel = []string{topic, sub, result}
 
 	gm *Metrics
 )
@@ -38,6 +44,25 @@ type Metrics struct {
 	MessagesHistogramVec      *prometheus.HistogramVec
 	MessagesSizeHistogramVec  *prometheus.HistogramVec
 
+	//gc
+	GCKeysCounterVec *prometheus.CounterVec
+
+	//topics failed to be trimmed
+	TrimFailuresCounterVec *prometheus.CounterVec
+
+	//expired messages dropped by pulls and the trimmer
+	MessagesExpiredCounterVec *prometheus.CounterVec
+
+	//push
+	PushMessagesCounterVec   *prometheus.CounterVec
+	PushRequestsHistogramVec *prometheus.HistogramVec
+
+	//stats of topics and subscriptions
+	TopicMessagesGaugeVec             *prometheus.GaugeVec
+	TopicBytesGaugeVec                *prometheus.GaugeVec
+	SubscriptionBacklogGaugeVec       *prometheus.GaugeVec
+	SubscriptionOldestUnackedGaugeVec *prometheus.GaugeVec
+
 	//logger
 	LogMetricsCounterVec *prometheus.CounterVec
 }
@@ -91,6 +116,79 @@ func init() {
 		}, optLabel)
 	prometheus.MustRegister(gm.MessagesSizeHistogramVec)
 
+	gm.GCKeysCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gc_keys_total",
+			Help:      "Number of keys deleted by gc",
+		}, gcKeysLabel)
+	prometheus.MustRegister(gm.GCKeysCounterVec)
+
+	gm.TrimFailuresCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "trim_failures_total",
+			Help:      "Number of topics failed to be trimmed",
+		}, gcKeysLabel)
+	prometheus.MustRegister(gm.TrimFailuresCounterVec)
+
+	gm.MessagesExpiredCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_expired_total",
+			Help:      "Number of expired messages dropped without being delivered",
+		}, optLabel)
+	prometheus.MustRegister(gm.MessagesExpiredCounterVec)
+
+	gm.PushMessagesCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "push_messages_total",
+			Help:      "Number of messages pushed to the endpoints of subscriptions",
+		}, resultLabel)
+	prometheus.MustRegister(gm.PushMessagesCounterVec)
+
+	gm.PushRequestsHistogramVec = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "push_requests_seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 20),
+			Help:      "The cost times of requests to the endpoints of subscriptions",
+		}, subLabel)
+	prometheus.MustRegister(gm.PushRequestsHistogramVec)
+
+	gm.TopicMessagesGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "topic_messages",
+			Help:      "Number of messages kept by a topic",
+		}, topicLabel)
+	prometheus.MustRegister(gm.TopicMessagesGaugeVec)
+
+	gm.TopicBytesGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "topic_bytes",
+			Help:      "Bytes of the messages kept by a topic",
+		}, topicLabel)
+	prometheus.MustRegister(gm.TopicBytesGaugeVec)
+
+	gm.SubscriptionBacklogGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscription_backlog",
+			Help:      "Number of messages not acked by a subscription",
+		}, subLabel)
+	prometheus.MustRegister(gm.SubscriptionBacklogGaugeVec)
+
+	gm.SubscriptionOldestUnackedGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscription_oldest_unacked_seconds",
+			Help:      "The age of the oldest message not acked by a subscription",
+		}, subLabel)
+	prometheus.MustRegister(gm.SubscriptionOldestUnackedGaugeVec)
+
 	gm.LogMetricsCounterVec = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: namespace,
@@ -0,0 +1,236 @@
+package tips
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tipsio/tips/store/pubsub"
+	"go.uber.org/zap"
+)
+
+// notifier wakes up the waiters of a topic once messages are published to it.
+// Publishing in this process notifies the waiters immediately, and the
+// publishing on other nodes is known by watching the versions of the topics
+// periodically, which costs a transaction per interval no matter how many
+// waiters there are.
+type notifier struct {
+	ps *pubsub.Pubsub
+	// node identifies this process in the versions of topics, it should be stable
+	// across restarts so that the versions of a node are overwritten rather than
+	// left behind in the storage
+	node []byte
+
+	mu sync.Mutex
+	// running is true once the publishing on other nodes is watched
+	running bool
+	topics  map[string]*watchedTopic
+	// dirty are the topics published in this process but not bumped yet
+	dirty map[string]*pubsub.Topic
+}
+
+// watchedTopic is a topic which has waiters
+type watchedTopic struct {
+	topic   *pubsub.Topic
+	waiters int
+	// ch is closed and replaced when messages are published
+	ch chan struct{}
+	// versions are the versions of other nodes seen last time, nil if not seen yet
+	versions map[string]int64
+}
+
+func newNotifier(ps *pubsub.Pubsub) *notifier {
+	return &notifier{
+		ps:     ps,
+		node:   pubsub.UUID(),
+		topics: make(map[string]*watchedTopic),
+		dirty:  make(map[string]*pubsub.Topic),
+	}
+}
+
+// Watcher is notified when messages are published to a topic
+type Watcher struct {
+	n  *notifier
+	wt *watchedTopic
+}
+
+// C returns a channel which is closed once messages are published, it
+// should be taken before pulling so that no message is missed
+func (w *Watcher) C() <-chan struct{} {
+	w.n.mu.Lock()
+	defer w.n.mu.Unlock()
+	return w.wt.ch
+}
+
+// Close stops watching the topic
+func (w *Watcher) Close() {
+	n := w.n
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	w.wt.waiters--
+	if w.wt.waiters == 0 {
+		delete(n.topics, string(w.wt.topic.ObjectID))
+	}
+}
+
+// watch starts watching a topic
+func (n *notifier) watch(t *pubsub.Topic) *Watcher {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	wt, ok := n.topics[string(t.ObjectID)]
+	if !ok {
+		wt = &watchedTopic{topic: t, ch: make(chan struct{})}
+		n.topics[string(t.ObjectID)] = wt
+	}
+	wt.waiters++
+	return &Watcher{n: n, wt: wt}
+}
+
+// notifyLocked wakes up the waiters of a topic, n.mu should be held
+func (n *notifier) notifyLocked(objectID []byte) {
+	if wt, ok := n.topics[string(objectID)]; ok {
+		close(wt.ch)
+		wt.ch = make(chan struct{})
+	}
+}
+
+// published notifies the waiters in this process and marks the topic to be bumped for other nodes
+func (n *notifier) published(t *pubsub.Topic) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifyLocked(t.ObjectID)
+	n.dirty[string(t.ObjectID)] = t
+}
+
+// watching returns true if the publishing on other nodes is notified
+func (n *notifier) watching() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.running
+}
+
+// start runs the notifier in background until the ctx is done
+func (n *notifier) start(ctx context.Context, interval time.Duration) {
+	n.mu.Lock()
+	n.running = true
+	n.mu.Unlock()
+	go n.run(ctx, interval)
+}
+
+// run bumps the versions of the topics published in this process and watches the
+// versions bumped by other nodes every interval until the ctx is done
+func (n *notifier) run(ctx context.Context, interval time.Duration) {
+	defer func() {
+		n.mu.Lock()
+		n.running = false
+		n.mu.Unlock()
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := n.bump(ctx); err != nil {
+			zap.L().Error("bump versions of topics failed", zap.Error(err))
+		}
+		if err := n.poll(ctx); err != nil {
+			zap.L().Error("watch versions of topics failed", zap.Error(err))
+		}
+	}
+}
+
+// bump bumps the versions of the topics published since last time
+func (n *notifier) bump(ctx context.Context) error {
+	n.mu.Lock()
+	dirty := n.dirty
+	n.dirty = make(map[string]*pubsub.Topic)
+	n.mu.Unlock()
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	txn, err := n.ps.Begin()
+	if err != nil {
+		n.restore(dirty)
+		return err
+	}
+	version := time.Now().UnixNano()
+	for _, t := range dirty {
+		if err := txn.Bump(t, n.node, version); err != nil {
+			txn.Rollback()
+			n.restore(dirty)
+			return err
+		}
+	}
+	if err := txn.Commit(ctx); err != nil {
+		n.restore(dirty)
+		return err
+	}
+	return nil
+}
+
+// restore puts back the topics whose versions failed to be bumped, so that they are
+// bumped next time, the ones published again in the meantime are kept
+func (n *notifier) restore(dirty map[string]*pubsub.Topic) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, t := range dirty {
+		if _, ok := n.dirty[id]; !ok {
+			n.dirty[id] = t
+		}
+	}
+}
+
+// poll reads the versions of the watched topics and notifies the topics bumped by other nodes
+func (n *notifier) poll(ctx context.Context) error {
+	n.mu.Lock()
+	topics := make([]*watchedTopic, 0, len(n.topics))
+	for _, wt := range n.topics {
+		topics = append(topics, wt)
+	}
+	n.mu.Unlock()
+	if len(topics) == 0 {
+		return nil
+	}
+
+	txn, err := n.ps.Begin()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+	versions := make([]map[string]int64, len(topics))
+	for i, wt := range topics {
+		if versions[i], err = txn.GetVersions(wt.topic); err != nil {
+			return err
+		}
+		// The bumping of this process has been notified
+		delete(versions[i], string(n.node))
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, wt := range topics {
+		// A topic seen the first time is notified as well, because the messages
+		// published before it is watched may be missed by the waiters
+		if wt.versions == nil || changed(wt.versions, versions[i]) {
+			n.notifyLocked(wt.topic.ObjectID)
+		}
+		wt.versions = versions[i]
+	}
+	return nil
+}
+
+func changed(a, b map[string]int64) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return true
+		}
+	}
+	return false
+}
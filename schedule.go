@@ -0,0 +1,152 @@
+package tips
+
+import (
+	"context"
+	"time"
+
+	"github.com/tipsio/tips/store/pubsub"
+	"go.uber.org/zap"
+)
+
+// Clock tells the time when the delayed messages are due, it is replaced in tests
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock replaces the clock used to publish and deliver the delayed messages and
+// to expire messages in pulls and trims, it should be called before publishing and
+// starting the scheduler and the trimmer
+func (ti *Tips) SetClock(clock Clock) {
+	ti.clock = clock
+}
+
+// now returns the time of the clock, the system time if no clock is set
+func (ti *Tips) now() time.Time {
+	if ti.clock == nil {
+		return systemClock{}.Now()
+	}
+	return ti.clock.Now()
+}
+
+// deliverAt returns the time in unix nanoseconds when a message is due, 0 if it
+// is delivered at once
+func (m *Message) deliverAt(now time.Time) (int64, error) {
+	if m.DeliverAt != 0 && m.Delay != 0 {
+		return 0, invalidArgument("only one of deliver at and delay can be set")
+	}
+	if m.Delay < 0 {
+		return 0, invalidArgument("delay should not be negative")
+	}
+	at := m.DeliverAt
+	if m.Delay > 0 {
+		at = now.Add(m.Delay).UnixNano()
+	}
+	if at <= now.UnixNano() {
+		return 0, nil
+	}
+	return at, nil
+}
+
+// DefaultScheduleBatchSize is the batch size of the scheduler if a non-positive one is given
+const DefaultScheduleBatchSize = 256
+
+// StartScheduler starts appending the due delayed messages to their topics every
+// interval in background until the ctx is done
+func (ti *Tips) StartScheduler(ctx context.Context, interval time.Duration, batchSize int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := ti.DeliverScheduled(ctx, batchSize); err != nil {
+				zap.L().Error("deliver scheduled messages failed", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// DeliverScheduled appends the delayed messages which are due to their topics, at most
+// batchSize ones in a transaction, and returns the number of delivered messages.
+// The schedulers of different nodes conflict on the same messages, so each of them
+// is delivered only once. A topic which fails is logged and retried in the next
+// round, so that it does not hold back the others. A non-positive batchSize would
+// never make progress, DefaultScheduleBatchSize is used instead.
+func (ti *Tips) DeliverScheduled(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultScheduleBatchSize
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return 0, err
+	}
+	topics, err := txn.GetTopics()
+	if err != nil {
+		txn.Rollback()
+		return 0, err
+	}
+	if err := txn.Rollback(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, t := range topics {
+		for done := false; !done; {
+			select {
+			case <-ctx.Done():
+				return total, ctx.Err()
+			default:
+			}
+			var count int
+			count, done, err = ti.deliverDue(ctx, t.Name, batchSize)
+			if err != nil {
+				zap.L().Error("deliver scheduled messages of topic failed",
+					zap.String("topic", t.Name), zap.Error(err))
+				break
+			}
+			total += count
+		}
+	}
+	return total, nil
+}
+
+// deliverDue appends a batch of the due messages of a topic in a transaction,
+// returns the number of delivered messages and true if there is no more
+func (ti *Tips) deliverDue(ctx context.Context, name string, limit int) (int, bool, error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	t, err := txn.GetTopic(name)
+	if err != nil {
+		txn.Rollback()
+		if err == pubsub.ErrNotFound {
+			// Deleted by others
+			return 0, true, nil
+		}
+		return 0, false, err
+	}
+
+	count, done, err := txn.DeliverDue(t, ti.now(), limit)
+	if err != nil {
+		txn.Rollback()
+		return 0, false, err
+	}
+	if count == 0 {
+		return 0, true, txn.Rollback()
+	}
+	if err := txn.Commit(ctx); err != nil {
+		return 0, false, err
+	}
+	ti.notifier.published(t)
+	return count, done, nil
+}
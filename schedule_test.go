@@ -0,0 +1,191 @@
+package tips
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func pullPayloads(t *testing.T, tips *Tips, sub, topic string) []string {
+	msgs, err := tips.Pull(context.Background(), &PullReq{SubName: sub, Topic: topic, Limit: 10, AutoACK: true})
+	assert.NoError(t, err)
+	var payloads []string
+	for _, msg := range msgs {
+		payloads = append(payloads, string(msg.Payload))
+	}
+	return payloads
+}
+
+func TestSchedule(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	ids, err := tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("later"), DeliverAt: clock.now.Add(time.Hour).UnixNano()},
+		{Payload: []byte("now")},
+		{Payload: []byte("soon"), Delay: time.Minute},
+		// Due already
+		{Payload: []byte("past"), DeliverAt: clock.now.Add(-time.Minute).UnixNano()},
+	}, "t1")
+	assert.NoError(t, err)
+	assert.Len(t, ids, 4)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			assert.NotEqual(t, ids[i], ids[j])
+		}
+	}
+	assert.Equal(t, []string{"now", "past"}, pullPayloads(t, tips, "s1", "t1"))
+
+	count, err := tips.DeliverScheduled(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Nil(t, pullPayloads(t, tips, "s1", "t1"))
+
+	clock.now = clock.now.Add(time.Minute)
+	count, err = tips.DeliverScheduled(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	// The delivered message has a new id and carries the one returned by the publish
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 10, AutoACK: true})
+	assert.NoError(t, err)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "soon", string(msgs[0].Payload))
+		assert.NotEqual(t, ids[2], msgs[0].ID)
+		assert.Equal(t, ids[2], msgs[0].ScheduleID)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	count, err = tips.DeliverScheduled(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"later"}, pullPayloads(t, tips, "s1", "t1"))
+	count, err = tips.DeliverScheduled(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestClock(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	// The age of a backlog is measured from the timestamp of the publish transaction
+	clock := &fakeClock{now: time.Now().Add(time.Hour)}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+	_, err = tips.Publish(ctx, []string{"1"}, "t1")
+	assert.NoError(t, err)
+
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 1, AckDeadline: time.Minute})
+	assert.NoError(t, err)
+	if !assert.Len(t, msgs, 1) {
+		return
+	}
+	assert.Equal(t, clock.now.UnixNano(), msgs[0].PublishTime)
+	assert.NoError(t, tips.ModifyAckDeadline(ctx, msgs[0].ID, "t1", "s1", 10*time.Minute))
+
+	clock.now = clock.now.Add(5 * time.Minute)
+	msgs, err = tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 0)
+	info, err := tips.Subscription(ctx, "s1", "t1")
+	assert.NoError(t, err)
+	assert.True(t, info.OldestUnackedAge > time.Hour)
+	stats, err := tips.Stats(ctx, 10, 10, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, stats, 1) && assert.Len(t, stats[0].Subscriptions, 1) {
+		assert.True(t, stats[0].Subscriptions[0].OldestUnackedAge > time.Hour)
+	}
+
+	clock.now = clock.now.Add(5 * time.Minute)
+	msgs, err = tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+}
+
+func TestScheduleNonPositiveBatchSize(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+	_, err = tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), Delay: time.Minute}}, "t1")
+	assert.NoError(t, err)
+
+	// A zero batch size falls back to the default instead of delivering nothing
+	clock.now = clock.now.Add(time.Minute)
+	count, err := tips.DeliverScheduled(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"1"}, pullPayloads(t, tips, "s1", "t1"))
+}
+
+func TestScheduleInvalid(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+
+	_, err = tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), Delay: -time.Second}}, "t1")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), Delay: time.Second, DeliverAt: time.Now().UnixNano()}}, "t1")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+}
+
+func TestScheduleDedup(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	ids, err := tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), DedupKey: "k1", Delay: time.Second}}, "t1")
+	assert.NoError(t, err)
+	retried, err := tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), DedupKey: "k1", Delay: time.Second}}, "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, ids, retried)
+
+	clock.now = clock.now.Add(time.Second)
+	count, err := tips.DeliverScheduled(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"1"}, pullPayloads(t, tips, "s1", "t1"))
+}
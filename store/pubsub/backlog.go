@@ -0,0 +1,181 @@
+package pubsub
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb/store/tikv/oracle"
+)
+
+// Backlog is the messages of a topic which have not been acked by a subscription
+type Backlog struct {
+	// Outstanding is the number of messages sent but not acked
+	Outstanding int64
+	// Undelivered is the number of messages after the Sent of the subscription,
+	// including the ones which are going to be skipped by its filter
+	Undelivered int64
+	// Oldest is the offset of the earliest unacked message, nil if there is none
+	Oldest *Offset
+	// Truncated is true if the scan stops at the limit, the counts are lower bounds then
+	Truncated bool
+}
+
+// OldestAge returns how long the earliest unacked message has waited
+func (b *Backlog) OldestAge(now time.Time) time.Duration {
+	if b.Oldest == nil {
+		return 0
+	}
+	// The TS of an offset is a timestamp of the transaction which publishes the message
+	published := oracle.ExtractPhysical(uint64(b.Oldest.TS))
+	if age := oracle.GetPhysical(now) - published; age > 0 {
+		return time.Duration(age) * time.Millisecond
+	}
+	return 0
+}
+
+// GetBacklog scans the leases and the undelivered messages of a subscription,
+// at most limit ones of each are counted so that it is bounded for a large backlog
+func (txn *Transaction) GetBacklog(t *Topic, s *Subscription, limit int) (*Backlog, error) {
+	b := &Backlog{}
+	if err := txn.ScanLeases(t, s, func(offset *Offset, lease *Lease) bool {
+		if b.Outstanding >= int64(limit) {
+			b.Truncated = true
+			return false
+		}
+		if b.Oldest == nil {
+			b.Oldest = offset
+		}
+		b.Outstanding++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := txn.Scan(t, s.Sent.Next(), func(id MessageID, message *Message) bool {
+		if b.Undelivered >= int64(limit) {
+			b.Truncated = true
+			return false
+		}
+		if b.Oldest == nil {
+			b.Oldest = id.Offset
+		}
+		b.Undelivered++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Head returns the offset of the latest message of a topic, nil if the topic is empty.
+// The latest message of every partition is looked up and the greatest one is returned
+func (txn *Transaction) Head(t *Topic) (*Offset, error) {
+	prefixes := [][]byte{MessageKey(t, nil)}
+	if t.Partitioned() {
+		prefixes = prefixes[:0]
+		for p := 0; p < t.Partitions; p++ {
+			prefixes = append(prefixes, PartitionKey(t, p))
+		}
+	}
+	var head *Offset
+	for _, prefix := range prefixes {
+		last, err := txn.lastOffset(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && (head == nil || last.Cmp(head) > 0) {
+			head = last
+		}
+	}
+	return head, nil
+}
+
+// lastOffset returns the greatest offset of the messages under a prefix, nil if there is none.
+// The store can not seek in reverse, so the TS of the latest transaction which appended
+// messages is bisected with forward seeks, then the messages of that transaction are scanned
+func (txn *Transaction) lastOffset(prefix []byte) (*Offset, error) {
+	last, err := txn.firstOffset(prefix, &Offset{})
+	if err != nil || last == nil {
+		return nil, err
+	}
+	// No message is appended after the start of this transaction
+	lo, hi := last.TS, int64(txn.t.StartTS())
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		offset, err := txn.firstOffset(prefix, &Offset{TS: mid})
+		if err != nil {
+			return nil, err
+		}
+		if offset == nil {
+			hi = mid - 1
+			continue
+		}
+		lo, last = offset.TS, offset
+	}
+
+	iter, err := txn.t.Seek(append(append([]byte{}, prefix...), last.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		key := iter.Key()[len(prefix):]
+		if len(key) != 16 {
+			return nil, ErrCorrupted
+		}
+		last = OffsetFromBytes(key)
+		if err := iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return last, nil
+}
+
+// firstOffset returns the least offset not less than the offset under a prefix, nil if there is none
+func (txn *Transaction) firstOffset(prefix []byte, offset *Offset) (*Offset, error) {
+	iter, err := txn.t.Seek(append(append([]byte{}, prefix...), offset.Bytes()...))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	if !iter.Valid() || !iter.Key().HasPrefix(prefix) {
+		return nil, nil
+	}
+	key := iter.Key()[len(prefix):]
+	if len(key) != 16 {
+		return nil, ErrCorrupted
+	}
+	return OffsetFromBytes(key), nil
+}
+
+// Size is the messages kept by a topic
+type Size struct {
+	Messages int64
+	// Bytes is the size of the encoded messages
+	Bytes int64
+	// Truncated is true if the scan stops at the limit, the counts are lower bounds then
+	Truncated bool
+}
+
+// GetSize scans the messages of a topic, at most limit ones are counted
+func (txn *Transaction) GetSize(t *Topic, limit int) (*Size, error) {
+	prefix := MessageKey(t, nil)
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	size := &Size{}
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		if size.Messages >= int64(limit) {
+			size.Truncated = true
+			break
+		}
+		size.Messages++
+		size.Bytes += int64(len(iter.Value()))
+		if err := iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return size, nil
+}
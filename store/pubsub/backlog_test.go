@@ -0,0 +1,110 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBacklog(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	subscription, err := txn.CreateSubscription(topic, "sub")
+	assert.NoError(t, err)
+	b, err := txn.GetBacklog(topic, subscription, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &Backlog{}, b)
+	assert.Equal(t, time.Duration(0), b.OldestAge(time.Now()))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	mids, err := txn.Append(topic, time.Now(), &Message{Payload: []byte("1")}, &Message{Payload: []byte("2")}, &Message{Payload: []byte("3")})
+	assert.NoError(t, err)
+	// The first message is sent but not acked
+	assert.NoError(t, txn.Lease(topic, subscription, mids[0].Offset, &Lease{Deadline: time.Now().UnixNano()}))
+	subscription.Sent = mids[0].Offset
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	b, err = txn.GetBacklog(topic, subscription, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), b.Outstanding)
+	assert.Equal(t, int64(2), b.Undelivered)
+	assert.Equal(t, mids[0].Offset, b.Oldest)
+	assert.False(t, b.Truncated)
+	published := time.Unix(0, oracle.ExtractPhysical(uint64(mids[0].TS))*int64(time.Millisecond))
+	assert.Equal(t, time.Minute, b.OldestAge(published.Add(time.Minute)))
+
+	b, err = txn.GetBacklog(topic, subscription, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), b.Outstanding)
+	assert.Equal(t, int64(1), b.Undelivered)
+	assert.True(t, b.Truncated)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
+
+func TestHead(t *testing.T) {
+	for _, partitions := range []int{0, 4} {
+		topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano(), Partitions: partitions}
+
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		head, err := txn.Head(topic)
+		assert.NoError(t, err)
+		assert.Nil(t, head)
+		assert.NoError(t, txn.Commit(context.Background()))
+
+		var last MessageID
+		for i := 0; i < 3; i++ {
+			txn, err = ps.Begin()
+			assert.NoError(t, err)
+			mids, err := txn.Append(topic, time.Now(), &Message{Payload: []byte("1")}, &Message{Payload: []byte("2")}, &Message{Payload: []byte("3")})
+			assert.NoError(t, err)
+			last = mids[2]
+			assert.NoError(t, txn.Commit(context.Background()))
+		}
+
+		txn, err = ps.Begin()
+		assert.NoError(t, err)
+		head, err = txn.Head(topic)
+		assert.NoError(t, err)
+		assert.Equal(t, last.Offset, head, "partitions %d", partitions)
+		assert.NoError(t, txn.Commit(context.Background()))
+	}
+}
+
+func TestSize(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	size, err := txn.GetSize(topic, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &Size{}, size)
+	_, err = txn.Append(topic, time.Now(), &Message{Payload: []byte("1")}, &Message{Payload: []byte("2")})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	size, err = txn.GetSize(topic, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), size.Messages)
+	assert.True(t, size.Bytes > 2)
+	assert.False(t, size.Truncated)
+	size, err = txn.GetSize(topic, 2)
+	assert.NoError(t, err)
+	assert.False(t, size.Truncated)
+	size, err = txn.GetSize(topic, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), size.Messages)
+	assert.True(t, size.Truncated)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
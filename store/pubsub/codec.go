@@ -0,0 +1,443 @@
+package pubsub
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/* Value encoding format
+*  {format}{fields}
+*
+*  The leading byte is the format of the value. Values written by the early
+*  versions are JSON objects which always begin with '{'.
+*
+*  In the binary format, fields are a sequence of {tag}{value}, the tag is a
+*  uvarint of field<<1|wiretype, a varint value is a zigzag varint and a bytes
+*  value is a uvarint length followed by the bytes. Zero values are omitted and
+*  unknown fields are skipped, so fields can be added without a new format.
+ */
+
+const (
+	formatJSON   = '{'
+	formatBinary = 1
+)
+
+const (
+	wireVarint = 0
+	wireBytes  = 1
+)
+
+// ErrCorrupted is returned when a value can not be decoded
+var ErrCorrupted = errors.New("corrupted value")
+
+// record is a value which can be encoded in the binary format
+type record interface {
+	encode(e *encoder)
+	// decodeField decodes a field, unknown fields should be skipped by d.skip()
+	decodeField(d *decoder, field int) error
+}
+
+// encode encodes a record in the binary format
+func encode(r record) []byte {
+	e := &encoder{buf: []byte{formatBinary}}
+	r.encode(e)
+	return e.buf
+}
+
+// decode decodes a record in the binary format or the legacy JSON format
+func decode(data []byte, r record) error {
+	if len(data) == 0 {
+		return ErrCorrupted
+	}
+	switch data[0] {
+	case formatJSON:
+		return json.Unmarshal(data, r)
+	case formatBinary:
+		return decodeFields(data[1:], r)
+	default:
+		return fmt.Errorf("unknown value format %d", data[0])
+	}
+}
+
+// decodeFields decodes the fields of a record
+func decodeFields(data []byte, r record) error {
+	d := &decoder{buf: data}
+	for len(d.buf) > 0 {
+		field, err := d.next()
+		if err != nil {
+			return err
+		}
+		if err := r.decodeField(d, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encoder appends fields to a buffer
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field int, wire int) {
+	e.buf = appendUvarint(e.buf, uint64(field<<1|wire))
+}
+
+func (e *encoder) varint(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	e.buf = append(e.buf, b[:n]...)
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.varint(field, 1)
+	}
+}
+
+func (e *encoder) bytes(field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) string(field int, v string) {
+	e.bytes(field, []byte(v))
+}
+
+func (e *encoder) offset(field int, v *Offset) {
+	if v != nil {
+		e.bytes(field, v.Bytes())
+	}
+}
+
+// record encodes a nested record, the caller should skip nil records
+func (e *encoder) record(field int, r record) {
+	nested := &encoder{}
+	r.encode(nested)
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(nested.buf)))
+	e.buf = append(e.buf, nested.buf...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+// decoder reads fields from a buffer
+type decoder struct {
+	buf  []byte
+	wire int
+}
+
+// next reads the tag of the next field
+func (d *decoder) next() (int, error) {
+	tag, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, ErrCorrupted
+	}
+	d.buf = d.buf[n:]
+	d.wire = int(tag & 1)
+	return int(tag >> 1), nil
+}
+
+func (d *decoder) varint() (int64, error) {
+	if d.wire != wireVarint {
+		return 0, ErrCorrupted
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		return 0, ErrCorrupted
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *decoder) int(v *int) error {
+	i, err := d.varint()
+	*v = int(i)
+	return err
+}
+
+func (d *decoder) int64(v *int64) error {
+	i, err := d.varint()
+	*v = i
+	return err
+}
+
+func (d *decoder) duration(v *time.Duration) error {
+	i, err := d.varint()
+	*v = time.Duration(i)
+	return err
+}
+
+func (d *decoder) bool(v *bool) error {
+	i, err := d.varint()
+	*v = i != 0
+	return err
+}
+
+// bytes returns the bytes value, it is copied so it does not refer to the buffer
+func (d *decoder) bytes() ([]byte, error) {
+	if d.wire != wireBytes {
+		return nil, ErrCorrupted
+	}
+	l, n := binary.Uvarint(d.buf)
+	if n <= 0 || uint64(len(d.buf)-n) < l {
+		return nil, ErrCorrupted
+	}
+	v := make([]byte, l)
+	copy(v, d.buf[n:n+int(l)])
+	d.buf = d.buf[n+int(l):]
+	return v, nil
+}
+
+func (d *decoder) string(v *string) error {
+	b, err := d.bytes()
+	*v = string(b)
+	return err
+}
+
+func (d *decoder) offset() (*Offset, error) {
+	b, err := d.bytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 16 {
+		return nil, ErrCorrupted
+	}
+	return OffsetFromBytes(b), nil
+}
+
+func (d *decoder) record(r record) error {
+	b, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	return decodeFields(b, r)
+}
+
+// skip skips the value of an unknown field
+func (d *decoder) skip() error {
+	if d.wire == wireVarint {
+		_, err := d.varint()
+		return err
+	}
+	_, err := d.bytes()
+	return err
+}
+
+func (t *Topic) encode(e *encoder) {
+	e.string(1, t.Name)
+	e.bytes(2, t.ObjectID)
+	e.varint(3, t.CreatedAt)
+	if t.Retention != nil {
+		e.record(4, t.Retention)
+	}
+	e.varint(5, int64(t.TTL))
+	e.varint(6, int64(t.Partitions))
+}
+
+func (t *Topic) decodeField(d *decoder, field int) (err error) {
+	switch field {
+	case 1:
+		return d.string(&t.Name)
+	case 2:
+		t.ObjectID, err = d.bytes()
+		return err
+	case 3:
+		return d.int64(&t.CreatedAt)
+	case 4:
+		t.Retention = &Retention{}
+		return d.record(t.Retention)
+	case 5:
+		return d.duration(&t.TTL)
+	case 6:
+		return d.int(&t.Partitions)
+	}
+	return d.skip()
+}
+
+func (r *Retention) encode(e *encoder) {
+	e.varint(1, int64(r.MaxAge))
+	e.varint(2, r.MaxCount)
+	e.bool(3, r.Acked)
+}
+
+func (r *Retention) decodeField(d *decoder, field int) error {
+	switch field {
+	case 1:
+		return d.duration(&r.MaxAge)
+	case 2:
+		return d.int64(&r.MaxCount)
+	case 3:
+		return d.bool(&r.Acked)
+	}
+	return d.skip()
+}
+
+func (s *Subscription) encode(e *encoder) {
+	e.string(1, s.Name)
+	e.offset(2, s.Sent)
+	e.offset(3, s.Acked)
+	e.string(4, s.DeadLetterTopic)
+	e.varint(5, int64(s.MaxDeliveryAttempts))
+	e.string(6, s.Filter)
+	e.string(7, s.PushEndpoint)
+	e.bool(8, s.Ordered)
+}
+
+func (s *Subscription) decodeField(d *decoder, field int) (err error) {
+	switch field {
+	case 1:
+		return d.string(&s.Name)
+	case 2:
+		s.Sent, err = d.offset()
+		return err
+	case 3:
+		s.Acked, err = d.offset()
+		return err
+	case 4:
+		return d.string(&s.DeadLetterTopic)
+	case 5:
+		return d.int(&s.MaxDeliveryAttempts)
+	case 6:
+		return d.string(&s.Filter)
+	case 7:
+		return d.string(&s.PushEndpoint)
+	case 8:
+		return d.bool(&s.Ordered)
+	}
+	return d.skip()
+}
+
+func (ss *Snapshot) encode(e *encoder) {
+	e.string(1, ss.Name)
+	if ss.Subscription != nil {
+		e.record(2, ss.Subscription)
+	}
+}
+
+func (ss *Snapshot) decodeField(d *decoder, field int) error {
+	switch field {
+	case 1:
+		return d.string(&ss.Name)
+	case 2:
+		ss.Subscription = &Subscription{}
+		return d.record(ss.Subscription)
+	}
+	return d.skip()
+}
+
+// attribute is a key value pair of the message attributes
+type attribute struct {
+	Key   string
+	Value string
+}
+
+func (a *attribute) encode(e *encoder) {
+	e.string(1, a.Key)
+	e.string(2, a.Value)
+}
+
+func (a *attribute) decodeField(d *decoder, field int) error {
+	switch field {
+	case 1:
+		return d.string(&a.Key)
+	case 2:
+		return d.string(&a.Value)
+	}
+	return d.skip()
+}
+
+func (m *Message) encode(e *encoder) {
+	e.bytes(1, m.Payload)
+	for k, v := range m.Attributes {
+		e.record(2, &attribute{Key: k, Value: v})
+	}
+	e.varint(3, m.PublishTime)
+	e.string(4, m.OrderingKey)
+	e.varint(5, m.DeliverAt)
+	e.varint(6, m.ExpireAt)
+	e.offset(7, m.ScheduleID)
+}
+
+func (m *Message) decodeField(d *decoder, field int) (err error) {
+	switch field {
+	case 1:
+		m.Payload, err = d.bytes()
+		return err
+	case 2:
+		a := &attribute{}
+		if err := d.record(a); err != nil {
+			return err
+		}
+		if m.Attributes == nil {
+			m.Attributes = make(map[string]string)
+		}
+		m.Attributes[a.Key] = a.Value
+		return nil
+	case 3:
+		return d.int64(&m.PublishTime)
+	case 4:
+		return d.string(&m.OrderingKey)
+	case 5:
+		return d.int64(&m.DeliverAt)
+	case 6:
+		return d.int64(&m.ExpireAt)
+	case 7:
+		m.ScheduleID, err = d.offset()
+		return err
+	}
+	return d.skip()
+}
+
+func (l *Lease) encode(e *encoder) {
+	e.varint(1, l.Deadline)
+	e.varint(2, int64(l.Attempts))
+	e.string(3, l.OrderingKey)
+}
+
+func (l *Lease) decodeField(d *decoder, field int) error {
+	switch field {
+	case 1:
+		return d.int64(&l.Deadline)
+	case 2:
+		return d.int(&l.Attempts)
+	case 3:
+		return d.string(&l.OrderingKey)
+	}
+	return d.skip()
+}
+
+func (ts *Tombstone) encode(e *encoder) {
+	e.bytes(1, ts.ObjectID)
+	e.varint(2, int64(ts.Stage))
+	e.bytes(3, ts.Next)
+}
+
+func (ts *Tombstone) decodeField(d *decoder, field int) (err error) {
+	switch field {
+	case 1:
+		ts.ObjectID, err = d.bytes()
+		return err
+	case 2:
+		return d.int(&ts.Stage)
+	case 3:
+		ts.Next, err = d.bytes()
+		return err
+	}
+	return d.skip()
+}
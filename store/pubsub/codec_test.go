@@ -0,0 +1,120 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	now := time.Now().UnixNano()
+	records := []struct {
+		in  record
+		new func() record
+	}{
+		{&Topic{Name: "t", ObjectID: UUID(), CreatedAt: now, Retention: &Retention{MaxAge: time.Hour, MaxCount: 10, Acked: true}}, func() record { return &Topic{} }},
+		{&Topic{Name: "t", ObjectID: UUID(), CreatedAt: now}, func() record { return &Topic{} }},
+		{&Topic{Name: "t", ObjectID: UUID(), CreatedAt: now, TTL: time.Minute}, func() record { return &Topic{} }},
+		{&Subscription{Name: "s", Sent: &Offset{now, 3}, Acked: &Offset{now, -1}, DeadLetterTopic: "dlt", MaxDeliveryAttempts: 5, Filter: "attributes:k", PushEndpoint: "http://localhost/push"}, func() record { return &Subscription{} }},
+		{&Subscription{Name: "s", Sent: &Offset{now, 0}, Acked: &Offset{now, 0}, Ordered: true}, func() record { return &Subscription{} }},
+		{&Snapshot{Name: "ss", Subscription: &Subscription{Name: "s", Sent: &Offset{now, 0}, Acked: &Offset{}}}, func() record { return &Snapshot{} }},
+		{&Message{Payload: []byte("hello tips"), Attributes: map[string]string{"k1": "v1", "k2": ""}, PublishTime: now}, func() record { return &Message{} }},
+		{&Message{Payload: []byte("hello tips"), PublishTime: now, OrderingKey: "k1"}, func() record { return &Message{} }},
+		{&Message{Payload: []byte("later"), PublishTime: now, DeliverAt: now + 1, ExpireAt: now + 2, ScheduleID: &Offset{TS: 1, Index: 2}}, func() record { return &Message{} }},
+		{&Lease{Deadline: now, Attempts: 2}, func() record { return &Lease{} }},
+		{&Lease{Attempts: 1, OrderingKey: "k1"}, func() record { return &Lease{} }},
+		{&Tombstone{ObjectID: UUID(), Stage: 1, Next: []byte("M:")}, func() record { return &Tombstone{} }},
+	}
+	for _, r := range records {
+		data := encode(r.in)
+		assert.Equal(t, byte(formatBinary), data[0])
+		out := r.new()
+		assert.NoError(t, decode(data, out))
+		assert.Equal(t, r.in, out)
+
+		// The legacy JSON values are still readable
+		data, err := json.Marshal(r.in)
+		assert.NoError(t, err)
+		out = r.new()
+		assert.NoError(t, decode(data, out))
+		assert.Equal(t, r.in, out)
+	}
+}
+
+func TestCodecUnknownField(t *testing.T) {
+	e := &encoder{buf: []byte{formatBinary}}
+	(&Lease{Deadline: 1, Attempts: 2}).encode(e)
+	e.varint(100, 1)
+	e.string(101, "unknown")
+
+	lease := &Lease{}
+	assert.NoError(t, decode(e.buf, lease))
+	assert.Equal(t, &Lease{Deadline: 1, Attempts: 2}, lease)
+}
+
+func TestCodecCorrupted(t *testing.T) {
+	data := encode(&Topic{Name: "t", ObjectID: UUID()})
+	assert.Equal(t, ErrCorrupted, decode(data[:len(data)-1], &Topic{}))
+	assert.Equal(t, ErrCorrupted, decode(nil, &Topic{}))
+	assert.Error(t, decode([]byte{0xff}, &Topic{}))
+
+	// The wire type does not match the field
+	e := &encoder{buf: []byte{formatBinary}}
+	e.string(1, "deadline")
+	assert.Equal(t, ErrCorrupted, decode(e.buf, &Lease{}))
+}
+
+func TestMigrate(t *testing.T) {
+	now := time.Now().UnixNano()
+	topic := &Topic{Name: "migrate", ObjectID: UUID(), CreatedAt: now}
+	sub := &Subscription{Name: "s", Sent: &Offset{now, 0}, Acked: &Offset{now, 0}}
+	msg := &Message{Payload: []byte("hello tips")}
+	values := map[string]interface{}{
+		string(TopicKey(topic.Name)):                  topic,
+		string(SubscriptionKey(topic, sub.Name)):      sub,
+		string(SnapshotKey(topic, sub, "ss")):         &Snapshot{Name: "ss", Subscription: sub},
+		string(MessageKey(topic, &Offset{now, 0})):    msg,
+		string(MessageKey(topic, &Offset{now, 1})):    msg,
+		string(LeaseKey(topic, sub, &Offset{now, 0})): &Lease{Deadline: now, Attempts: 1},
+	}
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	for k, v := range values {
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.t.Set([]byte(k), data))
+	}
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	n, err := ps.Migrate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(values), n)
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	for k := range values {
+		val, err := txn.t.Get([]byte(k))
+		assert.NoError(t, err)
+		assert.Equal(t, byte(formatBinary), val[0])
+	}
+	got, err := txn.GetSubscription(topic, sub.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, sub, got)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	// Migrated values are skipped
+	n, err = ps.Migrate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	for k := range values {
+		assert.NoError(t, txn.t.Delete([]byte(k)))
+	}
+	assert.NoError(t, txn.Commit(context.Background()))
+}
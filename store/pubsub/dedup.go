@@ -0,0 +1,119 @@
+package pubsub
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+/* Messages published with a dedup key are indexed by the key
+*  D:{objectid}:{key} // the message published with a dedup key
+*
+*  An entry is valid within the dedup window after it is written, the expired
+*  ones are ignored and deleted by the trimmer later.
+ */
+
+// DedupKey builds a key of a dedup entry, returns the prefix of all the entries
+// of the topic if key is empty
+func DedupKey(t *Topic, key string) []byte {
+	var k []byte
+	k = append(k, 'D', ':')
+	k = append(k, t.ObjectID...)
+	k = append(k, ':')
+	k = append(k, []byte(key)...)
+	return k
+}
+
+// Dedup is the message published with a dedup key
+type Dedup struct {
+	Offset *Offset
+	// CreatedAt is the time in unix nanoseconds when the message is published
+	CreatedAt int64
+}
+
+func (d *Dedup) encode(e *encoder) {
+	e.offset(1, d.Offset)
+	e.varint(2, d.CreatedAt)
+}
+
+func (d *Dedup) decodeField(dec *decoder, field int) (err error) {
+	switch field {
+	case 1:
+		d.Offset, err = dec.offset()
+		return err
+	case 2:
+		return dec.int64(&d.CreatedAt)
+	}
+	return dec.skip()
+}
+
+// expired returns true if the entry is out of the window at now
+func (d *Dedup) expired(now time.Time, window time.Duration) bool {
+	return now.Sub(time.Unix(0, d.CreatedAt)) >= window
+}
+
+// GetDedup returns the offset of the message published with the key within the
+// window, ErrNotFound is returned if there is none or it has expired
+func (txn *Transaction) GetDedup(t *Topic, key string, now time.Time, window time.Duration) (*Offset, error) {
+	val, err := txn.t.Get(DedupKey(t, key))
+	if err != nil {
+		if !kv.IsErrNotFound(err) {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	d := &Dedup{}
+	if err := decode(val, d); err != nil {
+		return nil, err
+	}
+	if d.expired(now, window) {
+		return nil, ErrNotFound
+	}
+	return d.Offset, nil
+}
+
+// SetDedup records the offset of the message published with the key, two
+// transactions setting the same key conflict so only one of them commits
+func (txn *Transaction) SetDedup(t *Topic, key string, offset *Offset, now time.Time) error {
+	d := &Dedup{Offset: offset, CreatedAt: now.UnixNano()}
+	return txn.t.Set(DedupKey(t, key), encode(d))
+}
+
+// TrimDedup deletes at most limit expired dedup entries of a topic, returns the
+// number of deleted entries and true if there is nothing more to trim
+func (txn *Transaction) TrimDedup(t *Topic, now time.Time, window time.Duration, limit int) (int, bool, error) {
+	prefix := DedupKey(t, "")
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return 0, false, err
+	}
+	// Entries are ordered by keys rather than time, so all of them are checked
+	var keys []kv.Key
+	done := true
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		d := &Dedup{}
+		if err := decode(iter.Value(), d); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+		if d.expired(now, window) {
+			if len(keys) >= limit {
+				done = false
+				break
+			}
+			keys = append(keys, iter.Key().Clone())
+		}
+		if err := iter.Next(); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+	}
+	iter.Close()
+
+	for _, k := range keys {
+		if err := txn.t.Delete(k); err != nil {
+			return 0, false, err
+		}
+	}
+	return len(keys), done, nil
+}
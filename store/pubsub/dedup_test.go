@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupKey(t *testing.T) {
+	topic := &Topic{ObjectID: []byte("id")}
+	assert.Equal(t, []byte("D:id:"), DedupKey(topic, ""))
+	assert.Equal(t, []byte("D:id:k1"), DedupKey(topic, "k1"))
+}
+
+func TestDedup(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+	now := time.Now()
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	_, err = txn.GetDedup(topic, "k1", now, time.Minute)
+	assert.Equal(t, ErrNotFound, err)
+	mids, err := txn.Append(topic, time.Now(), &Message{Payload: []byte("1")}, &Message{Payload: []byte("2")})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.SetDedup(topic, "k1", mids[0].Offset, now.Add(-2*time.Minute)))
+	assert.NoError(t, txn.SetDedup(topic, "k2", mids[1].Offset, now))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	offset, err := txn.GetDedup(topic, "k2", now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, mids[1].Offset, offset)
+	// Expired
+	_, err = txn.GetDedup(topic, "k1", now, time.Minute)
+	assert.Equal(t, ErrNotFound, err)
+	offset, err = txn.GetDedup(topic, "k1", now, 3*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, mids[0].Offset, offset)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	count, done, err := txn.TrimDedup(topic, now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, 1, countKeys(t, DedupKey(topic, "")))
+
+	// Both are expired, but only one is deleted in a batch
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.SetDedup(topic, "k3", mids[0].Offset, now.Add(-2*time.Minute)))
+	count, done, err = txn.TrimDedup(topic, now.Add(time.Minute), time.Minute, 1)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, 1, countKeys(t, DedupKey(topic, "")))
+}
+
+func TestTrimmerDedup(t *testing.T) {
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	topic, err := txn.CreateTopic("trim-dedup")
+	assert.NoError(t, err)
+	mids, err := txn.Append(topic, time.Now(), &Message{Payload: []byte("1")})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.SetDedup(topic, "k1", mids[0].Offset, time.Now().Add(-time.Hour)))
+	assert.NoError(t, txn.SetDedup(topic, "k2", mids[0].Offset, time.Now().Add(-time.Hour)))
+	assert.NoError(t, txn.SetDedup(topic, "k3", mids[0].Offset, time.Now()))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	// Disabled
+	assert.NoError(t, NewTrimmer(ps, time.Second, 1, 0).Trim(context.Background()))
+	assert.Equal(t, 3, countKeys(t, DedupKey(topic, "")))
+
+	assert.NoError(t, NewTrimmer(ps, time.Second, 1, time.Minute).Trim(context.Background()))
+	assert.Equal(t, 1, countKeys(t, DedupKey(topic, "")))
+	// The messages are kept since the topic has no retention
+	assert.Equal(t, 1, countKeys(t, MessageKey(topic, nil)))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.DeleteTopic("trim-dedup"))
+	assert.NoError(t, txn.Commit(context.Background()))
+	for done := false; !done; {
+		txn, err = ps.Begin()
+		assert.NoError(t, err)
+		done, _, err = txn.sweep(topic.ObjectID, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(context.Background()))
+	}
+	assert.Equal(t, 0, countKeys(t, DedupKey(topic, "")))
+}
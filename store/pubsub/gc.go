@@ -1,5 +1,238 @@
 package pubsub
 
-func gc(prefix []byte) error {
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/tipsio/tips/metrics"
+	"go.uber.org/zap"
+)
+
+/* A deleted topic leaves a tombstone in the GC keyspace
+*  G:{objectid} // tombstone of a deleted topic
+*
+*  The GC worker walks every tombstone and removes the keys which belong to
+*  the deleted topic prefix by prefix, the progress is saved in the tombstone
+*  so the work can be resumed after a restart.
+ */
+
+// GCKey builds a key of a tombstone, returns the prefix of all tombstones if objectID is nil
+func GCKey(objectID []byte) []byte {
+	var key []byte
+	key = append(key, 'G', ':')
+	key = append(key, objectID...)
+	return key
+}
+
+// Tombstone records the garbage collecting progress of a deleted topic
+type Tombstone struct {
+	ObjectID []byte
+	// Stage is the index of the prefix being collected
+	Stage int
+	// Next is the key to resume from in the current stage
+	Next []byte
+}
+
+// gcPrefix is a key range owned by a topic
+type gcPrefix struct {
+	label  string
+	prefix func(t *Topic) []byte
+}
+
+// gcPrefixes lists all the key ranges of a topic in the collecting order
+var gcPrefixes = []gcPrefix{
+	{"message", func(t *Topic) []byte { return MessageKey(t, nil) }},
+	{"subscription", func(t *Topic) []byte { return SubscriptionKey(t, "") }},
+	{"snapshot", func(t *Topic) []byte { return SnapshotKey(t, nil, "") }},
+	{"lease", func(t *Topic) []byte { return LeaseKey(t, nil, nil) }},
+	{"notify", func(t *Topic) []byte { return NotifyKey(t, nil) }},
+	{"dedup", func(t *Topic) []byte { return DedupKey(t, "") }},
+	{"schedule", func(t *Topic) []byte { return ScheduleKey(t, 0, nil) }},
+}
+
+// gc records a tombstone of the topic, the keys of the topic are removed by GC later
+func (txn *Transaction) gc(t *Topic) error {
+	tombstone := &Tombstone{ObjectID: t.ObjectID}
+	data := encode(tombstone)
+	return txn.t.Set(GCKey(t.ObjectID), data)
+}
+
+// GetTombstones lists all the tombstones which have not been collected
+func (txn *Transaction) GetTombstones() ([]*Tombstone, error) {
+	prefix := GCKey(nil)
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var tombstones []*Tombstone
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		ts := &Tombstone{}
+		if err := decode(iter.Value(), ts); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, ts)
+		if err := iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return tombstones, nil
+}
+
+// GC removes the keys of deleted topics in background
+type GC struct {
+	ps        *Pubsub
+	interval  time.Duration
+	batchSize int
+}
+
+// DefaultGCBatchSize is the batch size of the GC if a non-positive one is given
+const DefaultGCBatchSize = 256
+
+// NewGC creates a garbage collector, at most batchSize keys are deleted in a transaction.
+// A non-positive batchSize would never make progress, DefaultGCBatchSize is used instead.
+func NewGC(ps *Pubsub, interval time.Duration, batchSize int) *GC {
+	if batchSize <= 0 {
+		batchSize = DefaultGCBatchSize
+	}
+	return &GC{ps: ps, interval: interval, batchSize: batchSize}
+}
+
+// Run collects garbage every interval until the ctx is done
+func (gc *GC) Run(ctx context.Context) {
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := gc.Collect(ctx); err != nil {
+			zap.L().Error("gc failed", zap.Error(err))
+		}
+	}
+}
+
+// Collect walks all tombstones and removes the keys of them
+func (gc *GC) Collect(ctx context.Context) error {
+	txn, err := gc.ps.Begin()
+	if err != nil {
+		return err
+	}
+	tombstones, err := txn.GetTombstones()
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	if err := txn.Rollback(); err != nil {
+		return err
+	}
+
+	for _, ts := range tombstones {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			done, err := gc.sweep(ctx, ts.ObjectID)
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+		}
+	}
 	return nil
 }
+
+// sweep deletes a batch of keys of a tombstone in a transaction, returns true if
+// all the keys of the tombstone have been deleted
+func (gc *GC) sweep(ctx context.Context, objectID []byte) (bool, error) {
+	txn, err := gc.ps.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	done, counts, err := txn.sweep(objectID, gc.batchSize)
+	if err != nil {
+		txn.Rollback()
+		return false, err
+	}
+	if err := txn.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	for label, count := range counts {
+		metrics.GetMetrics().GCKeysCounterVec.WithLabelValues(label).Add(float64(count))
+	}
+	return done, nil
+}
+
+// sweep deletes at most limit keys of a tombstone and saves the progress
+func (txn *Transaction) sweep(objectID []byte, limit int) (bool, map[string]int, error) {
+	key := GCKey(objectID)
+	val, err := txn.t.Get(key)
+	if err != nil {
+		if kv.IsErrNotFound(err) {
+			// Collected by others
+			return true, nil, nil
+		}
+		return false, nil, err
+	}
+	ts := &Tombstone{}
+	if err := decode(val, ts); err != nil {
+		return false, nil, err
+	}
+
+	t := &Topic{ObjectID: ts.ObjectID}
+	counts := make(map[string]int)
+	deleted := 0
+	for ts.Stage < len(gcPrefixes) && deleted < limit {
+		stage := gcPrefixes[ts.Stage]
+		prefix := stage.prefix(t)
+		start := ts.Next
+		if start == nil {
+			start = prefix
+		}
+		iter, err := txn.t.Seek(start)
+		if err != nil {
+			return false, nil, err
+		}
+		// Collect keys first, the iterator should not see the mutations of itself
+		var keys []kv.Key
+		for iter.Valid() && iter.Key().HasPrefix(prefix) && deleted+len(keys) < limit {
+			keys = append(keys, iter.Key().Clone())
+			if err := iter.Next(); err != nil {
+				iter.Close()
+				return false, nil, err
+			}
+		}
+		if iter.Valid() && iter.Key().HasPrefix(prefix) {
+			// The batch is full, resume from here next time
+			ts.Next = iter.Key().Clone()
+		} else {
+			ts.Stage++
+			ts.Next = nil
+		}
+		iter.Close()
+
+		for _, k := range keys {
+			if err := txn.t.Delete(k); err != nil {
+				return false, nil, err
+			}
+		}
+		deleted += len(keys)
+		counts[stage.label] += len(keys)
+	}
+
+	if ts.Stage >= len(gcPrefixes) {
+		return true, counts, txn.t.Delete(key)
+	}
+	data := encode(ts)
+	return false, counts, txn.t.Set(key, data)
+}
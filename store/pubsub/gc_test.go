@@ -0,0 +1,145 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupGarbage(name string) *Topic {
+	txn, err := ps.Begin()
+	if err != nil {
+		panic(err)
+	}
+	topic, err := txn.CreateTopic(name)
+	if err != nil {
+		panic(err)
+	}
+	messages := make([]*Message, 5)
+	for i := range messages {
+		messages[i] = &Message{Payload: []byte("hello tips")}
+	}
+	if _, err := txn.Append(topic, time.Now(), messages...); err != nil {
+		panic(err)
+	}
+	sub, err := txn.CreateSubscription(topic, "sub")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := txn.CreateSnapshot(topic, sub, "snap"); err != nil {
+		panic(err)
+	}
+	if err := txn.DeleteTopic(name); err != nil {
+		panic(err)
+	}
+	if err := txn.Commit(context.Background()); err != nil {
+		panic(err)
+	}
+	return topic
+}
+
+func countKeys(t *testing.T, prefix []byte) int {
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	defer txn.Rollback()
+
+	iter, err := txn.t.Seek(prefix)
+	assert.NoError(t, err)
+	defer iter.Close()
+
+	count := 0
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		count++
+		assert.NoError(t, iter.Next())
+	}
+	return count
+}
+
+func TestGCKey(t *testing.T) {
+	objectID := UUID()
+	var expected []byte
+	expected = append(expected, 'G', ':')
+	expected = append(expected, objectID...)
+	assert.Equal(t, expected, GCKey(objectID))
+}
+
+func TestDeleteTopicTombstone(t *testing.T) {
+	topic := SetupGarbage("gc-tombstone")
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	tombstones, err := txn.GetTombstones()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Rollback())
+
+	found := false
+	for _, ts := range tombstones {
+		if string(ts.ObjectID) == string(topic.ObjectID) {
+			found = true
+			assert.Equal(t, 0, ts.Stage)
+			assert.Nil(t, ts.Next)
+		}
+	}
+	assert.True(t, found)
+
+	assert.NoError(t, NewGC(ps, 0, 256).Collect(context.Background()))
+}
+
+func TestGCSweep(t *testing.T) {
+	topic := SetupGarbage("gc-sweep")
+
+	// Delete 3 messages and save the progress
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	done, counts, err := txn.sweep(topic.ObjectID, 3)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 3, counts["message"])
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, 2, countKeys(t, MessageKey(topic, nil)))
+
+	// Resume from the saved progress
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	done, counts, err = txn.sweep(topic.ObjectID, 3)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 2, counts["message"])
+	assert.Equal(t, 1, counts["subscription"])
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	done, counts, err = txn.sweep(topic.ObjectID, 3)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, 1, counts["snapshot"])
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	assert.Equal(t, 0, countKeys(t, MessageKey(topic, nil)))
+	assert.Equal(t, 0, countKeys(t, SubscriptionKey(topic, "")))
+	assert.Equal(t, 0, countKeys(t, SnapshotKey(topic, nil, "")))
+	assert.Equal(t, 0, countKeys(t, GCKey(topic.ObjectID)))
+}
+
+func TestGCCollect(t *testing.T) {
+	topic := SetupGarbage("gc-collect")
+
+	assert.NoError(t, NewGC(ps, 0, 2).Collect(context.Background()))
+
+	assert.Equal(t, 0, countKeys(t, MessageKey(topic, nil)))
+	assert.Equal(t, 0, countKeys(t, SubscriptionKey(topic, "")))
+	assert.Equal(t, 0, countKeys(t, SnapshotKey(topic, nil, "")))
+	assert.Equal(t, 0, countKeys(t, GCKey(nil)))
+}
+
+func TestGCNonPositiveBatchSize(t *testing.T) {
+	topic := SetupGarbage("gc-zero-batch")
+
+	// A zero batch size falls back to the default instead of looping forever
+	assert.Equal(t, DefaultGCBatchSize, NewGC(ps, 0, 0).batchSize)
+	assert.NoError(t, NewGC(ps, 0, 0).Collect(context.Background()))
+	assert.Equal(t, 0, countKeys(t, MessageKey(topic, nil)))
+}
@@ -0,0 +1,145 @@
+package pubsub
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+/* A message sent to a subscription but not acked holds a lease
+*  L:{objectid}:{len(subscription)}{subscription}{offset} // lease
+*
+*  The subscription name is prefixed by its length as a uvarint, so that the
+*  leases of a subscription are not scanned with those of another one whose
+*  name starts with it, such as "a" and "a:x".
+*
+*  The message is redelivered once the deadline of its lease has passed, and
+*  the lease is released when the message is acked.
+ */
+
+// Lease is the ack deadline of a message which has been sent but not acked
+type Lease struct {
+	// Deadline is the time in unix nanoseconds after which the message can be redelivered
+	Deadline int64
+	// Attempts is the number of times the message has been delivered, it is 0 if the
+	// message is held back by an ordered subscription and has never been delivered
+	Attempts int
+	// OrderingKey is the ordering key of the message, it is set only for ordered subscriptions
+	OrderingKey string
+}
+
+// Expired returns true if the deadline has passed
+func (l *Lease) Expired(now time.Time) bool {
+	return l.Deadline <= now.UnixNano()
+}
+
+// LeaseKey builds a key of a lease
+func LeaseKey(t *Topic, s *Subscription, offset *Offset) []byte {
+	var key []byte
+	key = append(key, 'L', ':')
+	key = append(key, t.ObjectID...)
+	key = append(key, ':')
+	if s != nil {
+		key = appendUvarint(key, uint64(len(s.Name)))
+		key = append(key, s.Name...)
+		if offset != nil {
+			key = append(key, offset.Bytes()...)
+		}
+	}
+	return key
+}
+
+// Lease sets the lease of a message sent to a subscription
+func (txn *Transaction) Lease(t *Topic, s *Subscription, offset *Offset, lease *Lease) error {
+	data := encode(lease)
+	return txn.t.Set(LeaseKey(t, s, offset), data)
+}
+
+// GetLease returns the lease of a message
+func (txn *Transaction) GetLease(t *Topic, s *Subscription, offset *Offset) (*Lease, error) {
+	val, err := txn.t.Get(LeaseKey(t, s, offset))
+	if err != nil {
+		if !kv.IsErrNotFound(err) {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+
+	lease := &Lease{}
+	if err := decode(val, lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Release deletes the lease of a message
+func (txn *Transaction) Release(t *Topic, s *Subscription, offset *Offset) error {
+	return txn.t.Delete(LeaseKey(t, s, offset))
+}
+
+// LeaseHandler is a handler to process scanned leases
+type LeaseHandler func(offset *Offset, lease *Lease) bool
+
+// ScanLeases calls handler for each lease of a subscription in the order of offset
+func (txn *Transaction) ScanLeases(t *Topic, s *Subscription, handler LeaseHandler) error {
+	prefix := LeaseKey(t, s, nil)
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		offset := OffsetFromBytes(iter.Key()[len(prefix):])
+		lease := &Lease{}
+		if err := decode(iter.Value(), lease); err != nil {
+			return err
+		}
+		if !handler(offset, lease) {
+			break
+		}
+		if err := iter.Next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseAll deletes all leases of a subscription
+func (txn *Transaction) ReleaseAll(t *Topic, s *Subscription) error {
+	var offsets []*Offset
+	if err := txn.ScanLeases(t, s, func(offset *Offset, lease *Lease) bool {
+		offsets = append(offsets, offset)
+		return true
+	}); err != nil {
+		return err
+	}
+	for _, offset := range offsets {
+		if err := txn.Release(t, s, offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdvanceAcked moves the Acked of a subscription to the offset right before
+// its earliest unacked message, or to the Sent if every sent message is acked.
+// Acked never moves backwards, such as the messages before it are pulled again
+// by an offset, it is only reset by seeking.
+func (txn *Transaction) AdvanceAcked(t *Topic, s *Subscription) error {
+	var earliest *Offset
+	if err := txn.ScanLeases(t, s, func(offset *Offset, lease *Lease) bool {
+		earliest = offset
+		return false
+	}); err != nil {
+		return err
+	}
+	acked := s.Sent
+	if earliest != nil {
+		acked = earliest.Prev()
+	}
+	if s.Acked == nil || acked.Cmp(s.Acked) > 0 {
+		s.Acked = acked
+	}
+	return nil
+}
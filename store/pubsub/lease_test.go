@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaseKey(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+	subscription := &Subscription{Name: "sub"}
+	offset := &Offset{time.Now().UnixNano(), 1}
+
+	var prefix []byte
+	prefix = append(prefix, 'L', ':')
+	prefix = append(prefix, topic.ObjectID...)
+	prefix = append(prefix, ':')
+	assert.Equal(t, prefix, LeaseKey(topic, nil, nil))
+
+	expected := append(prefix, 3)
+	expected = append(expected, []byte("sub")...)
+	assert.Equal(t, expected, LeaseKey(topic, subscription, nil))
+
+	expected = append(expected, offset.Bytes()...)
+	assert.Equal(t, expected, LeaseKey(topic, subscription, offset))
+}
+
+func TestScanLeasesPrefix(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+	a, ax := &Subscription{Name: "a"}, &Subscription{Name: "a:x"}
+	offset := &Offset{time.Now().UnixNano(), 0}
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Lease(topic, ax, offset, &Lease{Attempts: 1}))
+
+	// the leases of a:x are not the leases of a
+	count := 0
+	assert.NoError(t, txn.ScanLeases(topic, a, func(offset *Offset, lease *Lease) bool {
+		count++
+		return true
+	}))
+	assert.Equal(t, 0, count)
+	assert.NoError(t, txn.ScanLeases(topic, ax, func(offset *Offset, lease *Lease) bool {
+		count++
+		return true
+	}))
+	assert.Equal(t, 1, count)
+	assert.NoError(t, txn.Rollback())
+}
+
+func TestLease(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+	now := time.Now()
+	subscription := &Subscription{Name: "sub", Sent: &Offset{now.UnixNano(), 2}, Acked: &Offset{now.UnixNano(), -1}}
+	offsets := []*Offset{{now.UnixNano(), 0}, {now.UnixNano(), 1}, {now.UnixNano(), 2}}
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	for i, offset := range offsets {
+		assert.NoError(t, txn.Lease(topic, subscription, offset, &Lease{Deadline: now.Add(time.Duration(i) * time.Second).UnixNano(), Attempts: i + 1}))
+	}
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	lease, err := txn.GetLease(topic, subscription, offsets[1])
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lease.Attempts)
+	assert.False(t, lease.Expired(now))
+	assert.True(t, lease.Expired(now.Add(time.Second)))
+
+	var got []string
+	assert.NoError(t, txn.ScanLeases(topic, subscription, func(offset *Offset, lease *Lease) bool {
+		got = append(got, offset.String())
+		return true
+	}))
+	assert.Equal(t, []string{offsets[0].String(), offsets[1].String(), offsets[2].String()}, got)
+
+	// Acked stops before the earliest lease
+	assert.NoError(t, txn.Release(topic, subscription, offsets[1]))
+	assert.NoError(t, txn.AdvanceAcked(topic, subscription))
+	assert.Equal(t, offsets[0].Prev().String(), subscription.Acked.String())
+
+	assert.NoError(t, txn.Release(topic, subscription, offsets[0]))
+	assert.NoError(t, txn.AdvanceAcked(topic, subscription))
+	assert.Equal(t, offsets[1].String(), subscription.Acked.String())
+
+	assert.NoError(t, txn.ReleaseAll(topic, subscription))
+	assert.NoError(t, txn.AdvanceAcked(topic, subscription))
+	assert.Equal(t, subscription.Sent.String(), subscription.Acked.String())
+
+	// Acked does not move backwards even if an earlier message is leased again
+	assert.NoError(t, txn.Lease(topic, subscription, offsets[0], &Lease{Deadline: now.UnixNano()}))
+	assert.NoError(t, txn.AdvanceAcked(topic, subscription))
+	assert.Equal(t, subscription.Sent.String(), subscription.Acked.String())
+	assert.NoError(t, txn.Release(topic, subscription, offsets[0]))
+
+	lease, err = txn.GetLease(topic, subscription, offsets[2])
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, lease)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
@@ -0,0 +1,89 @@
+package pubsub
+
+// scan iterates the keys of {keyPrefix}{name} in order, where name begins with prefix
+// and is greater than after, until f returns false
+func (txn *Transaction) scan(keyPrefix []byte, prefix, after string, f func(value []byte) (bool, error)) error {
+	begin := append(append([]byte{}, keyPrefix...), prefix...)
+	bound := begin
+	if after != "" {
+		// The smallest key greater than {keyPrefix}{after}
+		next := append(append(append([]byte{}, keyPrefix...), after...), 0)
+		if string(next) > string(begin) {
+			begin = next
+		}
+	}
+
+	iter, err := txn.t.Seek(begin)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Valid() && iter.Key().HasPrefix(bound) {
+		more, err := f(iter.Value())
+		if err != nil || !more {
+			return err
+		}
+		if err := iter.Next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTopics returns at most limit topics in the order of names, the names begin
+// with prefix and are greater than after
+func (txn *Transaction) ListTopics(prefix, after string, limit int) ([]*Topic, error) {
+	var topics []*Topic
+	err := txn.scan(TopicKey(""), prefix, after, func(value []byte) (bool, error) {
+		t := &Topic{}
+		if err := decode(value, t); err != nil {
+			return false, err
+		}
+		topics = append(topics, t)
+		return len(topics) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// ListSubscriptions returns at most limit subscriptions of a topic in the order of
+// names, the names begin with prefix and are greater than after
+func (txn *Transaction) ListSubscriptions(t *Topic, prefix, after string, limit int) ([]*Subscription, error) {
+	var subs []*Subscription
+	err := txn.scan(SubscriptionKey(t, ""), prefix, after, func(value []byte) (bool, error) {
+		s := &Subscription{}
+		if err := decode(value, s); err != nil {
+			return false, err
+		}
+		subs = append(subs, s)
+		return len(subs) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ListSnapshots returns at most limit snapshots of a subscription in the order of
+// names, the names begin with prefix and are greater than after
+func (txn *Transaction) ListSnapshots(t *Topic, s *Subscription, prefix, after string, limit int) ([]*Snapshot, error) {
+	var snapshots []*Snapshot
+	err := txn.scan(SnapshotKey(t, s, ""), prefix, after, func(value []byte) (bool, error) {
+		ss := &Snapshot{}
+		if err := decode(value, ss); err != nil {
+			return false, err
+		}
+		// The keys of a subscription named {s.Name}:{suffix} share the prefix as well
+		if ss.Subscription != nil && ss.Subscription.Name != s.Name {
+			return true, nil
+		}
+		snapshots = append(snapshots, ss)
+		return len(snapshots) < limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
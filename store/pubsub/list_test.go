@@ -0,0 +1,99 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTopics(t *testing.T) {
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	for _, name := range []string{"list-a", "list-b", "list-c", "lista"} {
+		_, err := txn.CreateTopic(name)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, txn.Commit(context.Background()))
+	defer func() {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		for _, name := range []string{"list-a", "list-b", "list-c", "lista"} {
+			assert.NoError(t, txn.DeleteTopic(name))
+		}
+		assert.NoError(t, txn.Commit(context.Background()))
+	}()
+
+	names := func(topics []*Topic) []string {
+		var names []string
+		for _, t := range topics {
+			names = append(names, t.Name)
+		}
+		return names
+	}
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	topics, err := txn.ListTopics("list-", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"list-a", "list-b"}, names(topics))
+	topics, err = txn.ListTopics("list-", "list-b", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"list-c"}, names(topics))
+	// after is less than the prefix
+	topics, err = txn.ListTopics("list-b", "list-a", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"list-b"}, names(topics))
+	topics, err = txn.ListTopics("nolist", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, topics, 0)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
+
+func TestListSubscriptions(t *testing.T) {
+	topics := SetupTopics()
+	defer CleanupTopics(topics)
+	t1 := topics["t1"]
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	for _, name := range []string{"s1", "s2", "s2:1", "x"} {
+		_, err := txn.CreateSubscription(t1, name)
+		assert.NoError(t, err)
+	}
+	s2, err := txn.GetSubscription(t1, "s2")
+	assert.NoError(t, err)
+	s21, err := txn.GetSubscription(t1, "s2:1")
+	assert.NoError(t, err)
+	for _, name := range []string{"ss1", "ss2"} {
+		_, err := txn.CreateSnapshot(t1, s2, name)
+		assert.NoError(t, err)
+	}
+	_, err = txn.CreateSnapshot(t1, s21, "ss3")
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	subs, err := txn.ListSubscriptions(t1, "s", "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, subs, 3)
+	subs, err = txn.ListSubscriptions(t1, "", "s2", 10)
+	assert.NoError(t, err)
+	assert.Len(t, subs, 2)
+	assert.Equal(t, "s2:1", subs[0].Name)
+	assert.Equal(t, "x", subs[1].Name)
+
+	// The snapshots of s2:1 are not listed as the ones of s2
+	snapshots, err := txn.ListSnapshots(t1, s2, "", "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	snapshots, err = txn.ListSnapshots(t1, s2, "", "ss1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "ss2", snapshots[0].Name)
+	snapshots, err = txn.ListSnapshots(t1, s21, "", "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "ss3", snapshots[0].Name)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
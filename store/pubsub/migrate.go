@@ -0,0 +1,99 @@
+package pubsub
+
+import (
+	"context"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+// migration rewrites the values of a keyspace to the binary format
+type migration struct {
+	prefix []byte
+	record func() record
+}
+
+// migrations lists all the keyspaces which have values to be migrated
+var migrations = []migration{
+	{[]byte("T:"), func() record { return &Topic{} }},
+	{[]byte("S:"), func() record { return &Subscription{} }},
+	{[]byte("SS:"), func() record { return &Snapshot{} }},
+	{[]byte("M:"), func() record { return &Message{} }},
+	{[]byte("L:"), func() record { return &Lease{} }},
+	{[]byte("G:"), func() record { return &Tombstone{} }},
+}
+
+// Migrate rewrites all the values stored in the JSON format to the binary format,
+// at most batchSize keys are scanned in a transaction. It can be interrupted and
+// rerun safely, values which have been migrated are skipped. Returns the number of
+// migrated values.
+func (p *Pubsub) Migrate(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+	for _, m := range migrations {
+		start := m.prefix
+		for start != nil {
+			select {
+			case <-ctx.Done():
+				return total, ctx.Err()
+			default:
+			}
+
+			txn, err := p.Begin()
+			if err != nil {
+				return total, err
+			}
+			next, count, err := txn.migrate(m, start, batchSize)
+			if err != nil {
+				txn.Rollback()
+				return total, err
+			}
+			if err := txn.Commit(ctx); err != nil {
+				return total, err
+			}
+			total += count
+			start = next
+		}
+	}
+	return total, nil
+}
+
+// migrate rewrites the JSON values of at most limit keys from start, returns
+// the key to continue from, it is nil if the keyspace is done
+func (txn *Transaction) migrate(m migration, start []byte, limit int) ([]byte, int, error) {
+	iter, err := txn.t.Seek(start)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var keys []kv.Key
+	var values [][]byte
+	var next []byte
+	scanned := 0
+	for iter.Valid() && iter.Key().HasPrefix(m.prefix) {
+		if scanned >= limit {
+			next = iter.Key().Clone()
+			break
+		}
+		scanned++
+		if val := iter.Value(); len(val) > 0 && val[0] == formatJSON {
+			r := m.record()
+			if err := decode(val, r); err != nil {
+				iter.Close()
+				return nil, 0, err
+			}
+			keys = append(keys, iter.Key().Clone())
+			values = append(values, encode(r))
+		}
+		if err := iter.Next(); err != nil {
+			iter.Close()
+			return nil, 0, err
+		}
+	}
+	iter.Close()
+
+	for i := range keys {
+		if err := txn.t.Set(keys[i], values[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return next, len(keys), nil
+}
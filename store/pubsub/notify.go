@@ -0,0 +1,46 @@
+package pubsub
+
+/* Every node publishing to a topic keeps a version of the topic
+*  N:{objectid}:{node} // version of the topic bumped by a node
+*
+*  A node bumps the version after it published messages to the topic, the
+*  other nodes watch the versions to know new messages are available. Each
+*  node only writes its own key, so bumping never conflicts across nodes.
+ */
+
+// NotifyKey builds a key of the version of a topic, returns the prefix of all nodes if node is nil
+func NotifyKey(t *Topic, node []byte) []byte {
+	var key []byte
+	key = append(key, 'N', ':')
+	key = append(key, t.ObjectID...)
+	key = append(key, ':')
+	key = append(key, node...)
+	return key
+}
+
+// Bump sets the version of a topic of a node
+func (txn *Transaction) Bump(t *Topic, node []byte, version int64) error {
+	return txn.t.Set(NotifyKey(t, node), EncodeInt64(version))
+}
+
+// GetVersions returns the versions of a topic of all nodes
+func (txn *Transaction) GetVersions(t *Topic) (map[string]int64, error) {
+	prefix := NotifyKey(t, nil)
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	versions := make(map[string]int64)
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		if len(iter.Value()) != 8 {
+			return nil, ErrCorrupted
+		}
+		versions[string(iter.Key()[len(prefix):])] = DecodeInt64(iter.Value())
+		if err := iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
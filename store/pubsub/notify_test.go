@@ -0,0 +1,34 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersions(t *testing.T) {
+	topics := SetupTopics()
+	defer CleanupTopics(topics)
+	t1 := topics["t1"]
+	n1, n2 := UUID(), UUID()
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	versions, err := txn.GetVersions(t1)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 0)
+	assert.NoError(t, txn.Bump(t1, n1, 1))
+	assert.NoError(t, txn.Bump(t1, n2, 2))
+	// Versions of other topics are not included
+	assert.NoError(t, txn.Bump(topics["t2"], n1, 3))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Bump(t1, n1, 4))
+	versions, err = txn.GetVersions(t1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{string(n1): 4, string(n2): 2}, versions)
+	assert.NoError(t, txn.Commit(context.Background()))
+}
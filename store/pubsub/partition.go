@@ -0,0 +1,166 @@
+package pubsub
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sync/atomic"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+/* Messages of a partitioned topic are spread over its partitions
+*  M:{objectid}:{partition}{offset} // message of a partitioned topic
+*
+*  The partition is encoded in 2 bytes big endian, so the partitions are separate
+*  key ranges which can be split into different regions. A message is appended
+*  to the partition of its ordering key, or the partitions in turn if it has none.
+*  The index of an offset is a multiple of the partitions plus the partition, so
+*  the message ids are not changed and the messages appended in a transaction
+*  keep their order.
+ */
+
+// MaxPartitions is the max number of partitions of a topic
+const MaxPartitions = 256
+
+// roundRobin picks the partitions of the messages without an ordering key
+var roundRobin uint32
+
+// Partitioned returns true if the messages of the topic are spread over partitions
+func (t *Topic) Partitioned() bool {
+	return t.Partitions > 1
+}
+
+// PartitionKey builds the prefix of the messages in a partition of a topic
+func PartitionKey(t *Topic, partition int) []byte {
+	key := MessageKey(t, nil)
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(partition))
+	return append(key, b[:]...)
+}
+
+// partition returns the partition a message at the offset is appended to
+func (t *Topic) partition(offset *Offset) int {
+	p := int(offset.Index % int64(t.Partitions))
+	if p < 0 {
+		p += t.Partitions
+	}
+	return p
+}
+
+// pick chooses the partition of a message
+func (t *Topic) pick(m *Message) int {
+	if m.OrderingKey != "" {
+		h := fnv.New32a()
+		h.Write([]byte(m.OrderingKey))
+		return int(h.Sum32() % uint32(t.Partitions))
+	}
+	return int(atomic.AddUint32(&roundRobin, 1) % uint32(t.Partitions))
+}
+
+// messageIter iterates the messages of a topic in the order of offsets, the
+// partitions of a partitioned topic are merged
+type messageIter struct {
+	iters    []kv.Iterator
+	prefixes [][]byte
+	// heads are the offsets at the iterators, nil if one is exhausted
+	heads []*Offset
+	// cur is the iterator with the least offset, -1 if all are exhausted
+	cur int
+}
+
+// seekMessages returns an iterator at the first message not less than the offset
+func (txn *Transaction) seekMessages(t *Topic, offset *Offset) (*messageIter, error) {
+	if offset == nil {
+		offset = &Offset{}
+	}
+	var prefixes [][]byte
+	if t.Partitioned() {
+		for p := 0; p < t.Partitions; p++ {
+			prefixes = append(prefixes, PartitionKey(t, p))
+		}
+	} else {
+		prefixes = append(prefixes, MessageKey(t, nil))
+	}
+
+	it := &messageIter{prefixes: prefixes}
+	for _, prefix := range prefixes {
+		key := append(append([]byte{}, prefix...), offset.Bytes()...)
+		iter, err := txn.t.Seek(key)
+		if err != nil {
+			it.Close()
+			return nil, err
+		}
+		it.iters = append(it.iters, iter)
+		it.heads = append(it.heads, nil)
+		if err := it.load(len(it.iters) - 1); err != nil {
+			it.Close()
+			return nil, err
+		}
+	}
+	it.pick()
+	return it, nil
+}
+
+// load reads the offset at the i-th iterator
+func (it *messageIter) load(i int) error {
+	iter, prefix := it.iters[i], it.prefixes[i]
+	if !iter.Valid() || !iter.Key().HasPrefix(prefix) {
+		it.heads[i] = nil
+		return nil
+	}
+	key := iter.Key()[len(prefix):]
+	if len(key) != 16 {
+		return ErrCorrupted
+	}
+	it.heads[i] = OffsetFromBytes(key)
+	return nil
+}
+
+// pick points cur to the iterator with the least offset
+func (it *messageIter) pick() {
+	it.cur = -1
+	for i, head := range it.heads {
+		if head != nil && (it.cur < 0 || head.Cmp(it.heads[it.cur]) < 0) {
+			it.cur = i
+		}
+	}
+}
+
+// Valid returns false if there is no more message
+func (it *messageIter) Valid() bool {
+	return it.cur >= 0
+}
+
+// Offset returns the offset of the current message
+func (it *messageIter) Offset() *Offset {
+	return it.heads[it.cur]
+}
+
+// Key returns the key of the current message
+func (it *messageIter) Key() kv.Key {
+	return it.iters[it.cur].Key()
+}
+
+// Value returns the encoded current message
+func (it *messageIter) Value() []byte {
+	return it.iters[it.cur].Value()
+}
+
+// Next moves to the next message
+func (it *messageIter) Next() error {
+	if err := it.iters[it.cur].Next(); err != nil {
+		return err
+	}
+	if err := it.load(it.cur); err != nil {
+		return err
+	}
+	it.pick()
+	return nil
+}
+
+// Close closes the iterators of all partitions
+func (it *messageIter) Close() {
+	for _, iter := range it.iters {
+		iter.Close()
+	}
+}
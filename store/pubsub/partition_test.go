@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionKey(t *testing.T) {
+	topic := &Topic{ObjectID: []byte("id"), Partitions: 4}
+	assert.Equal(t, []byte("M:id:\x00\x03"), PartitionKey(topic, 3))
+	offset := &Offset{TS: 1, Index: 6}
+	assert.Equal(t, append([]byte("M:id:\x00\x02"), offset.Bytes()...), MessageKey(topic, offset))
+	assert.Equal(t, []byte("M:id:"), MessageKey(topic, nil))
+
+	// Not partitioned
+	topic.Partitions = 1
+	assert.Equal(t, append([]byte("M:id:"), offset.Bytes()...), MessageKey(topic, offset))
+}
+
+func TestPartitioned(t *testing.T) {
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	topic, err := txn.CreateTopic("partitioned")
+	assert.NoError(t, err)
+	topic.Partitions = 4
+	topic.Retention = &Retention{MaxCount: 10}
+	assert.NoError(t, txn.UpdateTopic(topic))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	var mids []MessageID
+	for i := 0; i < 2; i++ {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		ids, err := txn.Append(topic, time.Now(),
+			&Message{Payload: []byte("a"), OrderingKey: "a"},
+			&Message{Payload: []byte("1")},
+			&Message{Payload: []byte("2")},
+			&Message{Payload: []byte("3")},
+			&Message{Payload: []byte("4")},
+			&Message{Payload: []byte("a"), OrderingKey: "a"})
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(context.Background()))
+		mids = append(mids, ids...)
+	}
+	// The messages of a key are in the same partition
+	assert.Equal(t, topic.partition(mids[0].Offset), topic.partition(mids[5].Offset))
+	assert.Equal(t, topic.partition(mids[0].Offset), topic.partition(mids[11].Offset))
+	// The others are in turn
+	used := make(map[int]bool)
+	for _, id := range mids[1:5] {
+		used[topic.partition(id.Offset)] = true
+	}
+	assert.Len(t, used, 4)
+	total := 0
+	for p := 0; p < topic.Partitions; p++ {
+		total += countKeys(t, PartitionKey(topic, p))
+	}
+	assert.Equal(t, len(mids), total)
+
+	// The partitions are merged in the order of offsets
+	scan := func(begin *Offset) []string {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		defer txn.Rollback()
+		var got []string
+		assert.NoError(t, txn.Scan(topic, begin, func(id MessageID, m *Message) bool {
+			got = append(got, id.String())
+			return true
+		}))
+		return got
+	}
+	var expected []string
+	for _, id := range mids {
+		expected = append(expected, id.String())
+	}
+	assert.Equal(t, expected, scan(&Offset{}))
+	assert.Equal(t, expected[3:], scan(mids[3].Offset))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	m, err := txn.GetMessage(topic, mids[4].Offset)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", string(m.Payload))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	// The earliest messages of all partitions are trimmed
+	assert.NoError(t, NewTrimmer(ps, time.Second, 1, 0).Trim(context.Background()))
+	assert.Equal(t, expected[2:], scan(&Offset{}))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.DeleteTopic("partitioned"))
+	assert.NoError(t, txn.Commit(context.Background()))
+	for done := false; !done; {
+		txn, err = ps.Begin()
+		assert.NoError(t, err)
+		done, _, err = txn.sweep(topic.ObjectID, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(context.Background()))
+	}
+	assert.Equal(t, 0, countKeys(t, MessageKey(topic, nil)))
+}
@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/satori/go.uuid"
 )
 
@@ -19,11 +21,34 @@ import (
 *  S:{objectid}:{name} // subscription
 *  SS:{objectid}:{snapshot}:{name} // snapshot
 *  M:{topic}{offset} // message
+*  M:{topic}{partition}{offset} // message of a partitioned topic
+*  L:{objectid}:{len(subscription)}{subscription}{offset} // lease of an unacked message
+*  G:{objectid} // tombstone of a deleted topic
+*  N:{objectid}:{node} // version of a topic bumped by a node
+*  D:{objectid}:{key} // the message published with a dedup key
+*  Q:{objectid}:{deliverat}{offset} // a delayed message
 *
  */
 
 var ErrNotFound = errors.New("not found")
 
+// ErrInvalidOffset is returned when parsing a malformed offset or message id
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// IsAlreadyExists returns true if a key to be inserted has been written
+func IsAlreadyExists(err error) bool {
+	return kv.ErrKeyExists.Equal(err)
+}
+
+// IsUnavailable returns true if the storage can not be reached or is too busy
+// to serve in time
+func IsUnavailable(err error) bool {
+	return tikv.ErrTiKVServerTimeout.Equal(err) ||
+		tikv.ErrPDServerTimeout.Equal(err) ||
+		tikv.ErrRegionUnavailable.Equal(err) ||
+		tikv.ErrTiKVServerBusy.Equal(err)
+}
+
 // Offset is the position of a message in a topic
 type Offset struct {
 	TS    int64 // TS is the StartTS of the transaction
@@ -68,6 +93,23 @@ func (offset *Offset) String() string {
 	return fmt.Sprintf("%v-%v", offset.TS, offset.Index)
 }
 
+// Cmp compares two offsets, returns -1 if offset < o, 0 if they are equal and 1 if offset > o
+// The numbers are compared rather than the bytes, because the index of an offset
+// returned by Prev may be negative, which is not in order after encoded
+func (offset *Offset) Cmp(o *Offset) int {
+	switch {
+	case offset.TS < o.TS:
+		return -1
+	case offset.TS > o.TS:
+		return 1
+	case offset.Index < o.Index:
+		return -1
+	case offset.Index > o.Index:
+		return 1
+	}
+	return 0
+}
+
 // Next returns a greater offset
 func (offset *Offset) Next() *Offset {
 	o := *offset
@@ -75,6 +117,13 @@ func (offset *Offset) Next() *Offset {
 	return &o
 }
 
+// Prev returns a smaller offset, it is the greatest one which is less than offset
+func (offset *Offset) Prev() *Offset {
+	o := *offset
+	o.Index--
+	return &o
+}
+
 // OffsetFromBytes parses offset from bytes
 func OffsetFromBytes(d []byte) *Offset {
 	ts := DecodeInt64(d[:8])
@@ -82,11 +131,34 @@ func OffsetFromBytes(d []byte) *Offset {
 	return &Offset{TS: ts, Index: idx}
 }
 
-// OffsetFromString parses offset from a string
-func OffsetFromString(s string) *Offset {
-	offset := &Offset{}
-	fmt.Sscanf(s, "%d-%d", &offset.TS, &offset.Index)
-	return offset
+// OffsetFromString parses offset from a string in the format of {ts}-{index},
+// ErrInvalidOffset is returned if the string is malformed
+func OffsetFromString(s string) (*Offset, error) {
+	// The index may be negative, such as 1-0 of which Prev is 1--1
+	i := strings.IndexByte(s, '-')
+	if i <= 0 {
+		return nil, ErrInvalidOffset
+	}
+	ts, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil || ts < 0 {
+		return nil, ErrInvalidOffset
+	}
+	index, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidOffset
+	}
+	return &Offset{TS: ts, Index: index}, nil
+}
+
+// OffsetFromTime returns the greatest offset before the messages published at or after t
+func OffsetFromTime(t time.Time) *Offset {
+	ts := oracle.ComposeTS(oracle.GetPhysical(t), 0)
+	return (&Offset{TS: int64(ts)}).Prev()
+}
+
+// IsRetryableError returns true if the error is caused by transaction conflicts and worth to retry
+func IsRetryableError(err error) bool {
+	return kv.IsRetryableError(err)
 }
 
 // Pubsub is a storage with a pub/sub interface
@@ -140,6 +212,23 @@ type Topic struct {
 	Name      string
 	ObjectID  []byte
 	CreatedAt int64
+	Retention *Retention `json:",omitempty"`
+	// TTL is the time to live of the messages published without one, 0 means they never expire
+	TTL time.Duration `json:",omitempty"`
+	// Partitions is the number of key ranges the messages are spread over, the topic
+	// is not partitioned if it is 0 or 1. It can not be changed once set
+	Partitions int `json:",omitempty"`
+}
+
+// Retention is the policy to trim messages of a topic, a message is trimmed
+// once any of the enabled rules matches
+type Retention struct {
+	// MaxAge trims messages older than it, 0 means no limit
+	MaxAge time.Duration
+	// MaxCount keeps at most MaxCount latest messages, 0 means no limit
+	MaxCount int64
+	// Acked trims messages which have been acked by all subscriptions
+	Acked bool
 }
 
 // UUID generates a global unique ID
@@ -159,10 +248,7 @@ func (txn *Transaction) CreateTopic(name string) (*Topic, error) {
 			ObjectID:  UUID(),
 			CreatedAt: time.Now().UnixNano(),
 		}
-		data, err := json.Marshal(topic)
-		if err != nil {
-			return nil, err
-		}
+		data := encode(topic)
 		if err := txn.t.Set(key, data); err != nil {
 			return nil, err
 		}
@@ -170,7 +256,7 @@ func (txn *Transaction) CreateTopic(name string) (*Topic, error) {
 	}
 
 	topic := &Topic{}
-	if err := json.Unmarshal(val, topic); err != nil {
+	if err := decode(val, topic); err != nil {
 		return nil, err
 	}
 
@@ -183,7 +269,7 @@ func (txn *Transaction) DeleteTopic(name string) error {
 	if err != nil {
 		return err
 	}
-	if err := gc(MessageKey(topic, nil)); err != nil {
+	if err := txn.gc(topic); err != nil {
 		return err
 	}
 	return txn.t.Delete(TopicKey(name))
@@ -202,13 +288,43 @@ func (txn *Transaction) GetTopic(name string) (*Topic, error) {
 	}
 
 	topic := &Topic{}
-	if err := json.Unmarshal(val, topic); err != nil {
+	if err := decode(val, topic); err != nil {
 		return nil, err
 	}
 
 	return topic, nil
 }
 
+// UpdateTopic updates the meta of a topic
+func (txn *Transaction) UpdateTopic(t *Topic) error {
+	data := encode(t)
+	return txn.t.Set(TopicKey(t.Name), data)
+}
+
+// GetTopics lists all topics
+func (txn *Transaction) GetTopics() ([]*Topic, error) {
+	var topics []*Topic
+
+	prefix := TopicKey("")
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		t := &Topic{}
+		if err := decode(iter.Value(), t); err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+		if err := iter.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return topics, nil
+}
+
 // SubscriptionKey builds a key of a subscription
 func SubscriptionKey(topic *Topic, sub string) []byte {
 	var key []byte
@@ -224,6 +340,17 @@ type Subscription struct {
 	Name  string
 	Sent  *Offset
 	Acked *Offset
+	// DeadLetterTopic receives the messages which have been delivered
+	// MaxDeliveryAttempts times but not acked
+	DeadLetterTopic     string `json:",omitempty"`
+	MaxDeliveryAttempts int    `json:",omitempty"`
+	// Filter is an expression over the message attributes, only the matched messages are delivered
+	Filter string `json:",omitempty"`
+	// PushEndpoint is the URL where the messages are posted, it is a pull subscription if empty
+	PushEndpoint string `json:",omitempty"`
+	// Ordered delivers the messages with the same ordering key one by one, a message
+	// is not delivered until the earlier ones with the same key are acked
+	Ordered bool `json:",omitempty"`
 }
 
 // CreateSubscritpion creates a subscription
@@ -240,10 +367,7 @@ func (txn *Transaction) CreateSubscription(t *Topic, name string) (*Subscription
 			Sent:  &Offset{int64(txn.t.StartTS()), 0},
 			Acked: &Offset{int64(txn.t.StartTS()), 0},
 		}
-		data, err := json.Marshal(sub)
-		if err != nil {
-			return nil, err
-		}
+		data := encode(sub)
 		if err := txn.t.Set(key, data); err != nil {
 			return nil, err
 		}
@@ -251,15 +375,18 @@ func (txn *Transaction) CreateSubscription(t *Topic, name string) (*Subscription
 	}
 
 	sub := &Subscription{}
-	if err := json.Unmarshal(val, sub); err != nil {
+	if err := decode(val, sub); err != nil {
 		return nil, err
 	}
 
 	return sub, nil
 }
 
-// DeleteSubscription deletes a subscription
+// DeleteSubscription deletes a subscription and its leases
 func (txn *Transaction) DeleteSubscription(t *Topic, name string) error {
+	if err := txn.ReleaseAll(t, &Subscription{Name: name}); err != nil {
+		return err
+	}
 	key := SubscriptionKey(t, name)
 	return txn.t.Delete(key)
 }
@@ -277,7 +404,7 @@ func (txn *Transaction) GetSubscription(t *Topic, name string) (*Subscription, e
 	}
 
 	sub := &Subscription{}
-	if err := json.Unmarshal(val, sub); err != nil {
+	if err := decode(val, sub); err != nil {
 		return nil, err
 	}
 
@@ -296,7 +423,7 @@ func (txn *Transaction) GetSubscriptions(t *Topic) ([]*Subscription, error) {
 
 	for iter.Valid() && iter.Key().HasPrefix(prefix) {
 		sub := &Subscription{}
-		if err := json.Unmarshal(iter.Value(), sub); err != nil {
+		if err := decode(iter.Value(), sub); err != nil {
 			return nil, err
 		}
 		subscriptions = append(subscriptions, sub)
@@ -311,10 +438,7 @@ func (txn *Transaction) GetSubscriptions(t *Topic) ([]*Subscription, error) {
 func (txn *Transaction) UpdateSubscription(t *Topic, s *Subscription) error {
 	key := SubscriptionKey(t, s.Name)
 
-	val, err := json.Marshal(s)
-	if err != nil {
-		return err
-	}
+	val := encode(s)
 	return txn.t.Set(key, val)
 }
 
@@ -326,6 +450,25 @@ type MessageID struct {
 // Message wraps a bytes payload
 type Message struct {
 	Payload []byte
+	// Attributes are the headers attached by the producer, such as content-type
+	Attributes map[string]string `json:",omitempty"`
+	// PublishTime is the time in unix nanoseconds when the message is appended
+	PublishTime int64 `json:",omitempty"`
+	// OrderingKey groups the messages which are delivered in order by an ordered subscription
+	OrderingKey string `json:",omitempty"`
+	// DeliverAt is the time in unix nanoseconds when a delayed message is appended
+	DeliverAt int64 `json:",omitempty"`
+	// ExpireAt is the time in unix nanoseconds after which the message is dropped
+	// without being delivered, 0 means it never expires
+	ExpireAt int64 `json:",omitempty"`
+	// ScheduleID is the offset returned when a delayed message is scheduled, it is
+	// kept once the message is appended to the topic with a new offset
+	ScheduleID *Offset `json:",omitempty"`
+}
+
+// Expired returns true if the message should not be delivered at now
+func (m *Message) Expired(now time.Time) bool {
+	return m.ExpireAt > 0 && now.UnixNano() >= m.ExpireAt
 }
 
 // MessageKey builds a key of a message
@@ -335,21 +478,29 @@ func MessageKey(topic *Topic, offset *Offset) []byte {
 	key = append(key, topic.ObjectID...)
 	key = append(key, ':')
 	if offset != nil {
+		if topic.Partitioned() {
+			key = PartitionKey(topic, topic.partition(offset))
+		}
 		key = append(key, offset.Bytes()...)
 	}
 	return key
 }
 
-// Append a message to a topic
-func (txn *Transaction) Append(topic *Topic, messages ...*Message) ([]MessageID, error) {
+// Append a message to a topic, the messages are spread over the partitions if
+// the topic is partitioned. The messages without a publish time are published at now
+func (txn *Transaction) Append(topic *Topic, now time.Time, messages ...*Message) ([]MessageID, error) {
 	var mids []MessageID
 	for i := range messages {
 		offset := &Offset{TS: int64(txn.t.StartTS()), Index: int64(i)}
+		if topic.Partitioned() {
+			offset.Index = int64(i*topic.Partitions + topic.pick(messages[i]))
+		}
 		key := MessageKey(topic, offset)
-		data, err := json.Marshal(messages[i])
-		if err != nil {
-			return nil, err
+		// Keep the original publish time of a message moved from other topics
+		if messages[i].PublishTime == 0 {
+			messages[i].PublishTime = now.UnixNano()
 		}
+		data := encode(messages[i])
 
 		if err := txn.t.Set(key, data); err != nil {
 			return nil, err
@@ -359,25 +510,41 @@ func (txn *Transaction) Append(topic *Topic, messages ...*Message) ([]MessageID,
 	return mids, nil
 }
 
+// GetMessage returns the message at the offset
+func (txn *Transaction) GetMessage(topic *Topic, offset *Offset) (*Message, error) {
+	val, err := txn.t.Get(MessageKey(topic, offset))
+	if err != nil {
+		if !kv.IsErrNotFound(err) {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+
+	msg := &Message{}
+	if err := decode(val, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
 // ScanHandler is a handler to process scanned messages
 type ScanHandler func(id MessageID, message *Message) bool
 
-// Scan seeks to the offset and calls handler for each message
+// Scan seeks to the offset and calls handler for each message, the partitions
+// of a partitioned topic are merged in the order of offsets
 func (txn *Transaction) Scan(topic *Topic, offset *Offset, handler ScanHandler) error {
-	prefix := MessageKey(topic, nil)
-	key := MessageKey(topic, offset)
-	iter, err := txn.t.Seek(key)
+	iter, err := txn.seekMessages(topic, offset)
 	if err != nil {
 		return err
 	}
+	defer iter.Close()
 
-	for iter.Valid() && iter.Key().HasPrefix(prefix) {
-		offset := OffsetFromBytes(iter.Key()[len(prefix):])
+	for iter.Valid() {
 		msg := &Message{}
-		if err := json.Unmarshal(iter.Value(), msg); err != nil {
+		if err := decode(iter.Value(), msg); err != nil {
 			return err
 		}
-		if !handler(MessageID{offset}, msg) {
+		if !handler(MessageID{iter.Offset()}, msg) {
 			break
 		}
 		if err := iter.Next(); err != nil {
@@ -420,10 +587,7 @@ func (txn *Transaction) CreateSnapshot(topic *Topic, subscription *Subscription,
 			Name:         name,
 			Subscription: subscription,
 		}
-		data, err := json.Marshal(snapshot)
-		if err != nil {
-			return nil, err
-		}
+		data := encode(snapshot)
 		if err := txn.t.Set(key, data); err != nil {
 			return nil, err
 		}
@@ -431,7 +595,7 @@ func (txn *Transaction) CreateSnapshot(topic *Topic, subscription *Subscription,
 	}
 
 	snapshot := &Snapshot{}
-	if err := json.Unmarshal(val, snapshot); err != nil {
+	if err := decode(val, snapshot); err != nil {
 		return nil, err
 	}
 
@@ -450,7 +614,7 @@ func (txn *Transaction) GetSnapshot(topic *Topic, subscription *Subscription, na
 	}
 
 	snapshot := &Snapshot{}
-	if err := json.Unmarshal(val, snapshot); err != nil {
+	if err := decode(val, snapshot); err != nil {
 		return nil, err
 	}
 
@@ -473,7 +637,7 @@ func (txn *Transaction) GetSnapshots(topic *Topic, subscription *Subscription) (
 	var snapshots []*Snapshot
 	for iter.Valid() && iter.Key().HasPrefix(prefix) {
 		ss := &Snapshot{}
-		if err := json.Unmarshal(iter.Value(), ss); err != nil {
+		if err := decode(iter.Value(), ss); err != nil {
 			return nil, err
 		}
 		snapshots = append(snapshots, ss)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -59,11 +60,29 @@ func TestOffsetBytes(t *testing.T) {
 	assert.Equal(t, offset.Index, got.Index)
 }
 
+func mustOffset(s string) *Offset {
+	offset, err := OffsetFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return offset
+}
+
 func TestOffsetString(t *testing.T) {
 	offset := &Offset{time.Now().UnixNano(), 0}
-	got := OffsetFromString(offset.String())
+	got, err := OffsetFromString(offset.String())
+	assert.NoError(t, err)
 	assert.Equal(t, offset.TS, got.TS)
 	assert.Equal(t, offset.Index, got.Index)
+
+	got, err = OffsetFromString(offset.Prev().String())
+	assert.NoError(t, err)
+	assert.Equal(t, offset.Prev(), got)
+
+	for _, s := range []string{"", "1", "-1-1", "1-", "1-a", "a-1", "1-1x", " 1-1"} {
+		_, err = OffsetFromString(s)
+		assert.Equal(t, ErrInvalidOffset, err, s)
+	}
 }
 
 func TestOffsetNext(t *testing.T) {
@@ -73,6 +92,17 @@ func TestOffsetNext(t *testing.T) {
 	assert.Equal(t, offset.Index+1, got.Index)
 }
 
+func TestOffsetFromTime(t *testing.T) {
+	before := time.Now()
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	published := &Offset{int64(txn.t.StartTS()), 0}
+	assert.NoError(t, txn.Rollback())
+
+	assert.Equal(t, -1, OffsetFromTime(before.Add(-time.Millisecond)).Cmp(published))
+	assert.Equal(t, 1, OffsetFromTime(time.Now().Add(time.Millisecond)).Cmp(published))
+}
+
 func TestTopicKey(t *testing.T) {
 	assert.Equal(t, string(TopicKey("unittest")), "T:unittest")
 }
@@ -130,7 +160,7 @@ func TestCreateTopic(t *testing.T) {
 	assert.NoError(t, err)
 
 	got := &Topic{}
-	err = json.Unmarshal(val, got)
+	err = decode(val, got)
 	assert.NoError(t, err)
 
 	assert.Equal(t, topic.Name, got.Name)
@@ -263,7 +293,7 @@ func TestCreateSubscription(t *testing.T) {
 	assert.NotNil(t, val)
 
 	got := &Subscription{}
-	assert.NoError(t, json.Unmarshal(val, got))
+	assert.NoError(t, decode(val, got))
 
 	offset := &Offset{int64(txn.t.StartTS()), 0}
 	assert.Equal(t, sub.Name, got.Name)
@@ -422,7 +452,7 @@ func TestCreateSnapshot(t *testing.T) {
 	assert.NotNil(t, val)
 
 	got := &Snapshot{}
-	assert.NoError(t, json.Unmarshal(val, got))
+	assert.NoError(t, decode(val, got))
 
 	assert.Equal(t, snapshot.Subscription.Name, got.Subscription.Name)
 	assert.Equal(t, snapshot.Subscription.Sent.String(), got.Subscription.Sent.String())
@@ -439,7 +469,7 @@ func TestCreateSnapshot(t *testing.T) {
 	assert.NotNil(t, val)
 
 	got = &Snapshot{}
-	assert.NoError(t, json.Unmarshal(val, got))
+	assert.NoError(t, decode(val, got))
 
 	assert.Equal(t, snapshot.Subscription.Name, got.Subscription.Name)
 	assert.Equal(t, snapshot.Subscription.Sent.String(), got.Subscription.Sent.String())
@@ -595,7 +625,7 @@ func CleanupMessages(t *Topic, messages map[string]*Message) {
 		panic(err)
 	}
 	for o := range messages {
-		if err := txn.t.Delete(MessageKey(t, OffsetFromString(o))); err != nil {
+		if err := txn.t.Delete(MessageKey(t, mustOffset(o))); err != nil {
 			panic(err)
 		}
 	}
@@ -615,7 +645,7 @@ func TestAppend(t *testing.T) {
 	messages := make(map[string]*Message)
 	for i := 0; i < 3; i++ {
 		msg := &Message{Payload: []byte("hello tips")}
-		mids, err := txn.Append(topic, msg)
+		mids, err := txn.Append(topic, time.Now(), msg)
 		assert.NoError(t, err)
 		assert.NotNil(t, mids)
 		assert.Equal(t, 1, len(mids))
@@ -632,7 +662,7 @@ func TestAppend(t *testing.T) {
 
 	for iter.Valid() && iter.Key().HasPrefix(prefix) {
 		got := &Message{}
-		assert.NoError(t, json.Unmarshal(iter.Value(), &got))
+		assert.NoError(t, decode(iter.Value(), got))
 
 		offset := OffsetFromBytes(iter.Key()[len(prefix):])
 		m := messages[offset.String()]
@@ -671,3 +701,177 @@ func TestScan(t *testing.T) {
 
 	CleanupMessages(topic, messages)
 }
+
+// appendJSON appends messages in the legacy JSON format
+func appendJSON(txn *Transaction, topic *Topic, messages ...*Message) error {
+	for i := range messages {
+		data, err := json.Marshal(messages[i])
+		if err != nil {
+			return err
+		}
+		offset := &Offset{TS: int64(txn.t.StartTS()), Index: int64(i)}
+		if err := txn.t.Set(MessageKey(topic, offset), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendBinary appends messages in the binary format
+func appendBinary(txn *Transaction, topic *Topic, messages ...*Message) error {
+	_, err := txn.Append(topic, time.Now(), messages...)
+	return err
+}
+
+var benchmarkFormats = []struct {
+	name   string
+	append func(txn *Transaction, topic *Topic, messages ...*Message) error
+}{
+	{"json", appendJSON},
+	{"binary", appendBinary},
+}
+
+func benchmarkMessages(n int) []*Message {
+	payload := bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 256)
+	messages := make([]*Message, n)
+	for i := range messages {
+		messages[i] = &Message{
+			Payload:     payload,
+			Attributes:  map[string]string{"content-type": "application/octet-stream"},
+			PublishTime: time.Now().UnixNano(),
+		}
+	}
+	return messages
+}
+
+func BenchmarkAppend(b *testing.B) {
+	messages := benchmarkMessages(100)
+	for _, f := range benchmarkFormats {
+		b.Run(f.name, func(b *testing.B) {
+			topic := &Topic{Name: "benchmark", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+			b.SetBytes(int64(len(messages) * len(messages[0].Payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				txn, err := ps.Begin()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := f.append(txn, topic, messages...); err != nil {
+					b.Fatal(err)
+				}
+				if err := txn.Commit(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	messages := benchmarkMessages(1000)
+	for _, f := range benchmarkFormats {
+		b.Run(f.name, func(b *testing.B) {
+			topic := &Topic{Name: "benchmark", ObjectID: UUID(), CreatedAt: time.Now().UnixNano()}
+			txn, err := ps.Begin()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := f.append(txn, topic, messages...); err != nil {
+				b.Fatal(err)
+			}
+			if err := txn.Commit(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64(len(messages) * len(messages[0].Payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				txn, err := ps.Begin()
+				if err != nil {
+					b.Fatal(err)
+				}
+				count := 0
+				if err := txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+					count++
+					return true
+				}); err != nil {
+					b.Fatal(err)
+				}
+				if count != len(messages) {
+					b.Fatalf("scanned %d messages, expected %d", count, len(messages))
+				}
+				txn.Rollback()
+			}
+		})
+	}
+}
+
+// benchmarkPartitions compares the partitioned topics with an unpartitioned one, mocktikv
+// keeps all the keys in a region so it measures the overhead of the partitions rather
+// than the hotspot they avoid
+var benchmarkPartitions = []int{0, 4, 16}
+
+func BenchmarkAppendPartitioned(b *testing.B) {
+	messages := benchmarkMessages(100)
+	for _, n := range benchmarkPartitions {
+		b.Run(fmt.Sprintf("partitions-%d", n), func(b *testing.B) {
+			topic := &Topic{Name: "benchmark", ObjectID: UUID(), CreatedAt: time.Now().UnixNano(), Partitions: n}
+			b.SetBytes(int64(len(messages) * len(messages[0].Payload)))
+			b.ResetTimer()
+			// Publishers append to the topic concurrently
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					txn, err := ps.Begin()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := txn.Append(topic, time.Now(), messages...); err != nil {
+						b.Fatal(err)
+					}
+					if err := txn.Commit(context.Background()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkScanPartitioned(b *testing.B) {
+	messages := benchmarkMessages(1000)
+	for _, n := range benchmarkPartitions {
+		b.Run(fmt.Sprintf("partitions-%d", n), func(b *testing.B) {
+			topic := &Topic{Name: "benchmark", ObjectID: UUID(), CreatedAt: time.Now().UnixNano(), Partitions: n}
+			txn, err := ps.Begin()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := txn.Append(topic, time.Now(), messages...); err != nil {
+				b.Fatal(err)
+			}
+			if err := txn.Commit(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64(len(messages) * len(messages[0].Payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				txn, err := ps.Begin()
+				if err != nil {
+					b.Fatal(err)
+				}
+				count := 0
+				if err := txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+					count++
+					return true
+				}); err != nil {
+					b.Fatal(err)
+				}
+				if count != len(messages) {
+					b.Fatalf("scanned %d messages, expected %d", count, len(messages))
+				}
+				txn.Rollback()
+			}
+		})
+	}
+}
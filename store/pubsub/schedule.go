@@ -0,0 +1,106 @@
+package pubsub
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+/* Delayed messages are kept in the schedule index until they are due
+*  Q:{objectid}:{deliverat}{offset} // a delayed message
+*
+*  The deliverat is the due time in unix nanoseconds encoded in big endian, so
+*  the due messages are always a prefix of the index. They are appended to the
+*  topic once they are due and delivered with new message ids then, the offset
+*  in the key only identifies the scheduled message and is kept in its ScheduleID.
+ */
+
+// ScheduleKey builds a key of a delayed message, returns the prefix of all the
+// delayed messages of the topic if offset is nil
+func ScheduleKey(t *Topic, deliverAt int64, offset *Offset) []byte {
+	var key []byte
+	key = append(key, 'Q', ':')
+	key = append(key, t.ObjectID...)
+	key = append(key, ':')
+	if offset != nil {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(deliverAt))
+		key = append(key, b[:]...)
+		key = append(key, offset.Bytes()...)
+	}
+	return key
+}
+
+// Schedule adds delayed messages published at now which are appended to the topic
+// at their DeliverAt, the index of the returned offsets starts from first so that
+// they do not collide with the messages appended in the same transaction
+func (txn *Transaction) Schedule(t *Topic, now time.Time, first int, messages ...*Message) ([]MessageID, error) {
+	var mids []MessageID
+	for i := range messages {
+		offset := &Offset{TS: int64(txn.t.StartTS()), Index: int64(first + i)}
+		// The publish time is kept when the message is appended
+		if messages[i].PublishTime == 0 {
+			messages[i].PublishTime = now.UnixNano()
+		}
+		key := ScheduleKey(t, messages[i].DeliverAt, offset)
+		if err := txn.t.Set(key, encode(messages[i])); err != nil {
+			return nil, err
+		}
+		mids = append(mids, MessageID{offset})
+	}
+	return mids, nil
+}
+
+// DeliverDue appends at most limit delayed messages which are due at now to the
+// topic, returns the number of delivered messages and true if there is no more
+func (txn *Transaction) DeliverDue(t *Topic, now time.Time, limit int) (int, bool, error) {
+	prefix := ScheduleKey(t, 0, nil)
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return 0, false, err
+	}
+	var keys []kv.Key
+	var messages []*Message
+	done := true
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		key := iter.Key()[len(prefix):]
+		if len(key) != 8+16 {
+			iter.Close()
+			return 0, false, ErrCorrupted
+		}
+		if int64(binary.BigEndian.Uint64(key)) > now.UnixNano() {
+			break
+		}
+		if len(keys) >= limit {
+			done = false
+			break
+		}
+		msg := &Message{}
+		if err := decode(iter.Value(), msg); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+		msg.ScheduleID = OffsetFromBytes(key[8:])
+		keys = append(keys, iter.Key().Clone())
+		messages = append(messages, msg)
+		if err := iter.Next(); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+	}
+	iter.Close()
+
+	if len(messages) == 0 {
+		return 0, done, nil
+	}
+	if _, err := txn.Append(t, now, messages...); err != nil {
+		return 0, false, err
+	}
+	for _, k := range keys {
+		if err := txn.t.Delete(k); err != nil {
+			return 0, false, err
+		}
+	}
+	return len(messages), done, nil
+}
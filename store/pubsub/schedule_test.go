@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleKey(t *testing.T) {
+	topic := &Topic{ObjectID: []byte("id")}
+	assert.Equal(t, []byte("Q:id:"), ScheduleKey(topic, 0, nil))
+	key := ScheduleKey(topic, 1, &Offset{TS: 2, Index: 3})
+	assert.Equal(t, append([]byte("Q:id:\x00\x00\x00\x00\x00\x00\x00\x01"), (&Offset{TS: 2, Index: 3}).Bytes()...), key)
+	// Due messages go first
+	assert.True(t, string(ScheduleKey(topic, 1, &Offset{TS: 9})) < string(ScheduleKey(topic, 2, &Offset{TS: 1})))
+}
+
+func TestSchedule(t *testing.T) {
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	topic, err := txn.CreateTopic("schedule")
+	assert.NoError(t, err)
+	now := time.Now()
+	mids, err := txn.Append(topic, time.Now(), &Message{Payload: []byte("0")})
+	assert.NoError(t, err)
+	sids, err := txn.Schedule(topic, now, 1,
+		&Message{Payload: []byte("2"), DeliverAt: now.Add(2 * time.Second).UnixNano()},
+		&Message{Payload: []byte("1"), DeliverAt: now.Add(time.Second).UnixNano()},
+		&Message{Payload: []byte("3"), DeliverAt: now.Add(time.Minute).UnixNano()})
+	assert.NoError(t, err)
+	assert.Len(t, sids, 3)
+	assert.Equal(t, mids[0].TS, sids[0].TS)
+	assert.Equal(t, int64(1), sids[0].Index)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, 3, countKeys(t, ScheduleKey(topic, 0, nil)))
+
+	// The delivered messages keep the publish time and the offsets of the schedule
+	scheduled := map[string]*Offset{"1": sids[1].Offset, "2": sids[0].Offset, "3": sids[2].Offset}
+	payloads := func() []string {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		defer txn.Rollback()
+		var ps []string
+		assert.NoError(t, txn.Scan(topic, nil, func(id MessageID, m *Message) bool {
+			ps = append(ps, string(m.Payload))
+			assert.Equal(t, scheduled[string(m.Payload)], m.ScheduleID)
+			if m.ScheduleID != nil {
+				assert.Equal(t, now.UnixNano(), m.PublishTime)
+			}
+			return true
+		}))
+		return ps
+	}
+	deliver := func(now time.Time, limit int) (int, bool) {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		count, done, err := txn.DeliverDue(topic, now, limit)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(context.Background()))
+		return count, done
+	}
+
+	// Nothing is due
+	count, done := deliver(now, 10)
+	assert.Equal(t, 0, count)
+	assert.True(t, done)
+	assert.Equal(t, []string{"0"}, payloads())
+
+	// Both are due, but only one is delivered in a batch
+	count, done = deliver(now.Add(3*time.Second), 1)
+	assert.Equal(t, 1, count)
+	assert.False(t, done)
+	assert.Equal(t, []string{"0", "1"}, payloads())
+	count, done = deliver(now.Add(3*time.Second), 1)
+	assert.Equal(t, 1, count)
+	assert.True(t, done)
+	assert.Equal(t, []string{"0", "1", "2"}, payloads())
+	assert.Equal(t, 1, countKeys(t, ScheduleKey(topic, 0, nil)))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.DeleteTopic("schedule"))
+	assert.NoError(t, txn.Commit(context.Background()))
+	for done := false; !done; {
+		txn, err = ps.Begin()
+		assert.NoError(t, err)
+		done, _, err = txn.sweep(topic.ObjectID, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(context.Background()))
+	}
+	assert.Equal(t, 0, countKeys(t, ScheduleKey(topic, 0, nil)))
+}
@@ -0,0 +1,341 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+	"github.com/tipsio/tips/metrics"
+	"go.uber.org/zap"
+)
+
+// Trimmer deletes the messages out of the retention of topics, the expired messages
+// and the expired dedup entries in background
+type Trimmer struct {
+	ps          *Pubsub
+	interval    time.Duration
+	batchSize   int
+	dedupWindow time.Duration
+	now         func() time.Time
+}
+
+// DefaultTrimBatchSize is the batch size of the trimmer if a non-positive one is given
+const DefaultTrimBatchSize = 256
+
+// NewTrimmer creates a trimmer, at most batchSize keys are deleted in a transaction,
+// the dedup entries older than dedupWindow are deleted unless it is 0.
+// A non-positive batchSize would never make progress, DefaultTrimBatchSize is used instead.
+func NewTrimmer(ps *Pubsub, interval time.Duration, batchSize int, dedupWindow time.Duration) *Trimmer {
+	if batchSize <= 0 {
+		batchSize = DefaultTrimBatchSize
+	}
+	return &Trimmer{ps: ps, interval: interval, batchSize: batchSize, dedupWindow: dedupWindow, now: time.Now}
+}
+
+// SetClock replaces the function which tells the time the retention, the expiration
+// of messages and the dedup window are measured from
+func (tr *Trimmer) SetClock(now func() time.Time) {
+	tr.now = now
+}
+
+// Run trims topics every interval until the ctx is done
+func (tr *Trimmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(tr.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := tr.Trim(ctx); err != nil {
+			zap.L().Error("trim failed", zap.Error(err))
+		}
+	}
+}
+
+// Trim walks all topics, trims the ones which have a retention policy and
+// deletes the expired messages and dedup entries
+func (tr *Trimmer) Trim(ctx context.Context) error {
+	txn, err := tr.ps.Begin()
+	if err != nil {
+		return err
+	}
+	topics, err := txn.GetTopics()
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	if err := txn.Rollback(); err != nil {
+		return err
+	}
+
+	// A topic failing to be trimmed is logged and skipped, so that it does not
+	// keep the others from being trimmed
+	for _, t := range topics {
+		if t.Retention != nil {
+			if err := tr.trimRetention(ctx, t); err != nil {
+				if err := tr.failed(ctx, t, "trim", err); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tr.trimAll(ctx, t.Name, "expire", tr.trimExpired); err != nil {
+			if err := tr.failed(ctx, t, "expire", err); err != nil {
+				return err
+			}
+		}
+		if tr.dedupWindow > 0 {
+			if err := tr.trimAll(ctx, t.Name, "dedup", tr.trimDedup); err != nil {
+				if err := tr.failed(ctx, t, "dedup", err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// failed logs and counts an error of trimming a topic, returns the error of the ctx
+// if it is done, then the run should be stopped
+func (tr *Trimmer) failed(ctx context.Context, t *Topic, label string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	zap.L().Error("trim topic failed", zap.String("topic", t.Name), zap.String("trim", label), zap.Error(err))
+	metrics.GetMetrics().TrimFailuresCounterVec.WithLabelValues(label).Inc()
+	return nil
+}
+
+// trimFunc deletes at most limit keys of a topic, returns the number of deleted
+// keys and true if there is nothing more to trim
+type trimFunc func(txn *Transaction, t *Topic, limit int) (int, bool, error)
+
+// trimRetention trims the messages out of the retention of a topic. The messages
+// exceeding the max count are counted once for the run rather than for every batch,
+// and every trimmed message takes one off, as the trimmed ones are always the earliest.
+func (tr *Trimmer) trimRetention(ctx context.Context, t *Topic) error {
+	var excess int64
+	if t.Retention.MaxCount > 0 {
+		txn, err := tr.ps.Begin()
+		if err != nil {
+			return err
+		}
+		excess, err = txn.Excess(t)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+		if err := txn.Rollback(); err != nil {
+			return err
+		}
+	}
+	return tr.trimAll(ctx, t.Name, "trim", func(txn *Transaction, t *Topic, limit int) (int, bool, error) {
+		count, done, err := txn.Trim(t, tr.now(), excess, limit)
+		if err != nil {
+			return 0, false, err
+		}
+		excess -= int64(count)
+		return count, done, nil
+	})
+}
+
+func (tr *Trimmer) trimExpired(txn *Transaction, t *Topic, limit int) (int, bool, error) {
+	return txn.TrimExpired(t, tr.now(), limit)
+}
+
+func (tr *Trimmer) trimDedup(txn *Transaction, t *Topic, limit int) (int, bool, error) {
+	return txn.TrimDedup(t, tr.now(), tr.dedupWindow, limit)
+}
+
+// trimAll trims a topic batch by batch until there is nothing more to trim
+func (tr *Trimmer) trimAll(ctx context.Context, name string, label string, fn trimFunc) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		done, err := tr.trim(ctx, name, label, fn)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// trim deletes a batch of keys of a topic in a transaction, returns true if
+// there is nothing more to trim
+func (tr *Trimmer) trim(ctx context.Context, name string, label string, fn trimFunc) (bool, error) {
+	txn, err := tr.ps.Begin()
+	if err != nil {
+		return false, err
+	}
+	t, err := txn.GetTopic(name)
+	if err != nil {
+		txn.Rollback()
+		if err == ErrNotFound {
+			// Deleted by others
+			return true, nil
+		}
+		return false, err
+	}
+
+	count, done, err := fn(txn, t, tr.batchSize)
+	if err != nil {
+		txn.Rollback()
+		return false, err
+	}
+	if count == 0 {
+		return true, txn.Rollback()
+	}
+	if err := txn.Commit(ctx); err != nil {
+		return false, err
+	}
+	metrics.GetMetrics().GCKeysCounterVec.WithLabelValues(label).Add(float64(count))
+	if label == "expire" {
+		metrics.GetMetrics().MessagesExpiredCounterVec.WithLabelValues("trim").Add(float64(count))
+	}
+	return done, nil
+}
+
+// Excess returns the number of messages of a topic exceeding the max count of its
+// retention, it scans the whole topic
+func (txn *Transaction) Excess(t *Topic) (int64, error) {
+	r := t.Retention
+	if r == nil || r.MaxCount <= 0 {
+		return 0, nil
+	}
+	total, err := txn.count(MessageKey(t, nil))
+	if err != nil {
+		return 0, err
+	}
+	if total <= r.MaxCount {
+		return 0, nil
+	}
+	return total - r.MaxCount, nil
+}
+
+// Trim deletes at most limit messages out of the retention of a topic, excess is the
+// number of messages exceeding the max count given by Excess. It returns the number
+// of deleted messages and true if there is nothing more to trim.
+func (txn *Transaction) Trim(t *Topic, now time.Time, excess int64, limit int) (int, bool, error) {
+	r := t.Retention
+	if r == nil {
+		return 0, true, nil
+	}
+
+	// The acked boundary is the minimal acked offset of all subscriptions
+	var acked *Offset
+	if r.Acked {
+		subs, err := txn.GetSubscriptions(t)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, sub := range subs {
+			if acked == nil || sub.Acked.Cmp(acked) < 0 {
+				acked = sub.Acked
+			}
+		}
+	}
+
+	var deadline int64
+	if r.MaxAge > 0 {
+		deadline = oracle.GetPhysical(now.Add(-r.MaxAge))
+	}
+
+	iter, err := txn.seekMessages(t, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	// Messages are ordered by offset, so the trimmed ones are always a prefix of the topic
+	var keys []kv.Key
+	done := true
+	for iter.Valid() {
+		if len(keys) >= limit {
+			done = false
+			break
+		}
+		offset := iter.Offset()
+		expired := deadline > 0 && oracle.ExtractPhysical(uint64(offset.TS)) < deadline
+		acknowledged := acked != nil && offset.Cmp(acked) <= 0
+		exceeded := int64(len(keys)) < excess
+		if !expired && !acknowledged && !exceeded {
+			break
+		}
+		keys = append(keys, iter.Key().Clone())
+		if err := iter.Next(); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+	}
+	iter.Close()
+
+	for _, k := range keys {
+		if err := txn.t.Delete(k); err != nil {
+			return 0, false, err
+		}
+	}
+	return len(keys), done, nil
+}
+
+// TrimExpired deletes at most limit expired messages from the head of a topic, returns
+// the number of deleted messages and true if there is nothing more to trim. It stops
+// at the first message which has not expired, the expired ones after it are skipped
+// by pulls and deleted once the messages before them are trimmed.
+func (txn *Transaction) TrimExpired(t *Topic, now time.Time, limit int) (int, bool, error) {
+	iter, err := txn.seekMessages(t, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	var keys []kv.Key
+	done := true
+	for iter.Valid() {
+		if len(keys) >= limit {
+			done = false
+			break
+		}
+		msg := &Message{}
+		if err := decode(iter.Value(), msg); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+		if !msg.Expired(now) {
+			break
+		}
+		keys = append(keys, iter.Key().Clone())
+		if err := iter.Next(); err != nil {
+			iter.Close()
+			return 0, false, err
+		}
+	}
+	iter.Close()
+
+	for _, k := range keys {
+		if err := txn.t.Delete(k); err != nil {
+			return 0, false, err
+		}
+	}
+	return len(keys), done, nil
+}
+
+// count returns the number of keys with the prefix
+func (txn *Transaction) count(prefix []byte) (int64, error) {
+	iter, err := txn.t.Seek(prefix)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	var n int64
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		n++
+		if err := iter.Next(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,395 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/tipsio/tips/metrics"
+)
+
+func SetupRetention(name string, retention *Retention, n int) (*Topic, []MessageID) {
+	txn, err := ps.Begin()
+	if err != nil {
+		panic(err)
+	}
+	topic, err := txn.CreateTopic(name)
+	if err != nil {
+		panic(err)
+	}
+	topic.Retention = retention
+	if err := txn.UpdateTopic(topic); err != nil {
+		panic(err)
+	}
+	messages := make([]*Message, n)
+	for i := range messages {
+		messages[i] = &Message{Payload: []byte("hello tips")}
+	}
+	mids, err := txn.Append(topic, time.Now(), messages...)
+	if err != nil {
+		panic(err)
+	}
+	if err := txn.Commit(context.Background()); err != nil {
+		panic(err)
+	}
+	return topic, mids
+}
+
+func CleanupRetention(topic *Topic) {
+	txn, err := ps.Begin()
+	if err != nil {
+		panic(err)
+	}
+	if err := txn.DeleteTopic(topic.Name); err != nil {
+		panic(err)
+	}
+	if err := txn.Commit(context.Background()); err != nil {
+		panic(err)
+	}
+	if err := NewGC(ps, time.Second, 1024).Collect(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+func TestOffsetCmp(t *testing.T) {
+	a := &Offset{1, 0}
+	b := &Offset{1, 1}
+	c := &Offset{2, 0}
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(c))
+	assert.Equal(t, 1, c.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(&Offset{1, 0}))
+	// The negative index returned by Prev is less than 0
+	assert.Equal(t, -1, a.Prev().Cmp(a))
+	assert.Equal(t, 1, a.Cmp(a.Prev()))
+}
+
+func TestUpdateTopic(t *testing.T) {
+	retention := &Retention{MaxAge: time.Hour, MaxCount: 10, Acked: true}
+	topic, _ := SetupRetention("retention-update", retention, 0)
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	got, err := txn.GetTopic(topic.Name)
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, retention, got.Retention)
+
+	CleanupRetention(topic)
+}
+
+func TestGetTopics(t *testing.T) {
+	topics := SetupTopics()
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	got, err := txn.GetTopics()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	found := 0
+	for _, topic := range got {
+		if expected, ok := topics[topic.Name]; ok {
+			assert.Equal(t, expected.ObjectID, topic.ObjectID)
+			found++
+		}
+	}
+	assert.Equal(t, len(topics), found)
+
+	CleanupTopics(topics)
+}
+
+func TestTrimMaxCount(t *testing.T) {
+	topic, mids := SetupRetention("retention-count", &Retention{MaxCount: 3}, 5)
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	excess, err := txn.Excess(topic)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), excess)
+	n, done, err := txn.Trim(topic, time.Now(), excess, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	n, done, err = txn.Trim(topic, time.Now(), excess-1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	var got []string
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+		got = append(got, id.String())
+		return true
+	}))
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, []string{mids[2].String(), mids[3].String(), mids[4].String()}, got)
+
+	CleanupRetention(topic)
+}
+
+func TestTrimMaxAge(t *testing.T) {
+	topic, _ := SetupRetention("retention-age", &Retention{MaxAge: time.Minute}, 3)
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	n, done, err := txn.Trim(topic, time.Now(), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.True(t, done)
+
+	n, done, err = txn.Trim(topic, time.Now().Add(time.Hour), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.True(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	CleanupRetention(topic)
+}
+
+func TestTrimAcked(t *testing.T) {
+	topic, mids := SetupRetention("retention-acked", &Retention{Acked: true}, 5)
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.UpdateSubscription(topic, &Subscription{Name: "s1", Sent: mids[3].Offset, Acked: mids[3].Offset}))
+	assert.NoError(t, txn.UpdateSubscription(topic, &Subscription{Name: "s2", Sent: mids[1].Offset, Acked: mids[1].Offset}))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	tr := NewTrimmer(ps, time.Second, 1, 0)
+	assert.NoError(t, tr.Trim(context.Background()))
+
+	var got []string
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+		got = append(got, id.String())
+		return true
+	}))
+	assert.NoError(t, txn.DeleteSubscription(topic, "s1"))
+	assert.NoError(t, txn.DeleteSubscription(topic, "s2"))
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, []string{mids[2].String(), mids[3].String(), mids[4].String()}, got)
+
+	CleanupRetention(topic)
+}
+
+func TestTrimAckedUnackedFirst(t *testing.T) {
+	topic, mids := SetupRetention("retention-acked-first", &Retention{Acked: true}, 3)
+	assert.Equal(t, int64(0), mids[0].Index)
+
+	// The first message is sent but unacked, so Acked is right before it
+	sub := &Subscription{Name: "s1", Sent: mids[2].Offset}
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Lease(topic, sub, mids[0].Offset, &Lease{Deadline: time.Now().Add(time.Minute).UnixNano()}))
+	assert.NoError(t, txn.AdvanceAcked(topic, sub))
+	assert.Equal(t, mids[0].Offset.Prev(), sub.Acked)
+	assert.NoError(t, txn.UpdateSubscription(topic, sub))
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	assert.NoError(t, NewTrimmer(ps, time.Second, 10, 0).Trim(context.Background()))
+
+	var got []string
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+		got = append(got, id.String())
+		return true
+	}))
+	assert.NoError(t, txn.ReleaseAll(topic, sub))
+	assert.NoError(t, txn.DeleteSubscription(topic, "s1"))
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, []string{mids[0].String(), mids[1].String(), mids[2].String()}, got)
+
+	CleanupRetention(topic)
+}
+
+func TestTrimExpired(t *testing.T) {
+	topic, _ := SetupRetention("expired", nil, 0)
+	now := time.Now()
+	past, future := now.Add(-time.Minute).UnixNano(), now.Add(time.Hour).UnixNano()
+
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	mids, err := txn.Append(topic, time.Now(),
+		&Message{Payload: []byte("1"), ExpireAt: past},
+		&Message{Payload: []byte("2"), ExpireAt: past},
+		&Message{Payload: []byte("3"), ExpireAt: future},
+		&Message{Payload: []byte("4"), ExpireAt: past},
+		&Message{Payload: []byte("5")})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	n, done, err := txn.TrimExpired(topic, now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	// The expired ones behind an unexpired message are kept
+	assert.NoError(t, NewTrimmer(ps, time.Second, 10, 0).Trim(context.Background()))
+	var got []string
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+		got = append(got, id.String())
+		return true
+	}))
+	assert.Equal(t, []string{mids[2].String(), mids[3].String(), mids[4].String()}, got)
+
+	n, done, err = txn.TrimExpired(topic, now.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	CleanupRetention(topic)
+}
+
+func TestTrimmerClock(t *testing.T) {
+	topic, _ := SetupRetention("clock", nil, 0)
+	now := time.Now()
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	_, err = txn.Append(topic, now, &Message{Payload: []byte("1"), ExpireAt: now.Add(time.Hour).UnixNano()})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	count := func() int {
+		txn, err := ps.Begin()
+		assert.NoError(t, err)
+		defer txn.Rollback()
+		n := 0
+		assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+			n++
+			return true
+		}))
+		return n
+	}
+
+	tr := NewTrimmer(ps, time.Second, 10, 0)
+	assert.NoError(t, tr.Trim(context.Background()))
+	assert.Equal(t, 1, count())
+	expired := &dto.Metric{}
+	assert.NoError(t, metrics.GetMetrics().MessagesExpiredCounterVec.WithLabelValues("trim").Write(expired))
+	tr.SetClock(func() time.Time { return now.Add(2 * time.Hour) })
+	assert.NoError(t, tr.Trim(context.Background()))
+	assert.Equal(t, 0, count())
+	m := &dto.Metric{}
+	assert.NoError(t, metrics.GetMetrics().MessagesExpiredCounterVec.WithLabelValues("trim").Write(m))
+	assert.Equal(t, expired.GetCounter().GetValue()+1, m.GetCounter().GetValue())
+
+	CleanupRetention(topic)
+}
+
+func TestTrimFailed(t *testing.T) {
+	broken, _ := SetupRetention("trim-failed-1", nil, 0)
+	topic, _ := SetupRetention("trim-failed-2", nil, 0)
+	now := time.Now()
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, txn.t.Set(MessageKey(broken, &Offset{now.UnixNano(), 0}), []byte{0xff}))
+	_, err = txn.Append(topic, now, &Message{Payload: []byte("1"), ExpireAt: now.Add(-time.Minute).UnixNano()})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	// The topic failed to be trimmed is skipped
+	failures := &dto.Metric{}
+	assert.NoError(t, metrics.GetMetrics().TrimFailuresCounterVec.WithLabelValues("expire").Write(failures))
+	assert.NoError(t, NewTrimmer(ps, time.Second, 10, 0).Trim(context.Background()))
+	m := &dto.Metric{}
+	assert.NoError(t, metrics.GetMetrics().TrimFailuresCounterVec.WithLabelValues("expire").Write(m))
+	assert.Equal(t, failures.GetCounter().GetValue()+1, m.GetCounter().GetValue())
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	var got []string
+	assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+		got = append(got, id.String())
+		return true
+	}))
+	assert.Empty(t, got)
+	assert.NoError(t, txn.Rollback())
+
+	// The run is stopped once the ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, NewTrimmer(ps, time.Second, 10, 0).Trim(ctx))
+
+	CleanupRetention(broken)
+	CleanupRetention(topic)
+}
+
+func TestTrimExpiredPartitioned(t *testing.T) {
+	topic := &Topic{Name: "unittest", ObjectID: UUID(), CreatedAt: time.Now().UnixNano(), Partitions: 4}
+	now := time.Now()
+	past, future := now.Add(-time.Minute).UnixNano(), now.Add(time.Hour).UnixNano()
+
+	// The messages are spread over partitions by their ordering keys
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	mids, err := txn.Append(topic, time.Now(),
+		&Message{Payload: []byte("1"), ExpireAt: past, OrderingKey: "a"},
+		&Message{Payload: []byte("2"), ExpireAt: past, OrderingKey: "b"},
+		&Message{Payload: []byte("3"), ExpireAt: future, OrderingKey: "c"},
+		&Message{Payload: []byte("4"), ExpireAt: past, OrderingKey: "d"})
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	scan := func(txn *Transaction) []string {
+		var got []string
+		assert.NoError(t, txn.Scan(topic, &Offset{}, func(id MessageID, m *Message) bool {
+			got = append(got, id.String())
+			return true
+		}))
+		return got
+	}
+
+	// The partitions are merged, so the expired ones behind an unexpired message
+	// are kept even if they are in other partitions
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	n, done, err := txn.TrimExpired(topic, now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, done)
+	n, done, err = txn.TrimExpired(topic, now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, done)
+	assert.NoError(t, txn.Commit(context.Background()))
+
+	txn, err = ps.Begin()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mids[2].String(), mids[3].String()}, scan(txn))
+	n, done, err = txn.TrimExpired(topic, now.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, done)
+	assert.Empty(t, scan(txn))
+	assert.NoError(t, txn.Commit(context.Background()))
+}
+
+func TestTrimNonPositiveBatchSize(t *testing.T) {
+	topic, _ := SetupRetention("trim-zero-batch", &Retention{MaxCount: 1}, 3)
+
+	// A zero batch size falls back to the default instead of trimming nothing
+	assert.Equal(t, DefaultTrimBatchSize, NewTrimmer(ps, 0, 0, 0).batchSize)
+	assert.NoError(t, NewTrimmer(ps, 0, 0, 0).Trim(context.Background()))
+	txn, err := ps.Begin()
+	assert.NoError(t, err)
+	count, err := txn.count(MessageKey(topic, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, txn.Rollback())
+
+	CleanupRetention(topic)
+}
@@ -2,20 +2,54 @@ package tips
 
 import (
 	"context"
-	"fmt"
+	"encoding/base64"
+	"net/url"
+	"time"
 
+	"github.com/tipsio/tips/metrics"
 	"github.com/tipsio/tips/store/pubsub"
 	"go.uber.org/zap"
 )
 
 var (
-	// ErrNotFound no found error
-	ErrNotFound = "%s can not found"
+	// ErrInvalidToken is returned when listing with a token which is not returned by a previous list
+	ErrInvalidToken = invalidArgument("invalid continuation token")
+	// ErrInvalidOffset is returned when a message id or an offset is malformed
+	ErrInvalidOffset = pubsub.ErrInvalidOffset
 )
 
+// DefaultAckDeadline is the ack deadline of pulled messages if it is not given by the pull request
+const DefaultAckDeadline = 10 * time.Second
+
+// redeliveryInterval is the max duration a waiting pull sleeps if the notifier is not
+// started, so that the messages published on other nodes are pulled
+const redeliveryInterval = time.Second
+
+// DefaultListLimit is the number of items listed in a page if the limit is not given
+const DefaultListLimit = 100
+
+// MaxListLimit is the max number of items listed in a page
+const MaxListLimit = 1000
+
+// maxBacklogScan is the max number of messages scanned to count the backlog of a subscription
+const maxBacklogScan = 10000
+
+// DefaultDedupWindow is the window within which the messages published with the
+// same dedup key are written only once
+const DefaultDedupWindow = 10 * time.Minute
+
+// maxFiltered is the max number of messages skipped by the filter or held back by the
+// ordering of a subscription in a pull, and the max number of leases scanned beyond
+// the limit of a pull for the ordering keys
+const maxFiltered = 4096
+
 // Tips is a structure which encapsulates a pubsub instance
 type Tips struct {
-	ps *pubsub.Pubsub
+	ps          *pubsub.Pubsub
+	locks       subscriptionLocks
+	notifier    *notifier
+	dedupWindow time.Duration
+	clock       Clock
 }
 
 // PullReq is a structure which encapsulates the pull request information
@@ -25,6 +59,11 @@ type PullReq struct {
 	Limit   int64
 	AutoACK bool
 	Offset  string
+	// AckDeadline is the duration before the pulled messages are redelivered
+	// if they are not acked, DefaultAckDeadline is used if it is 0
+	AckDeadline time.Duration
+	// Wait blocks the pull until there are messages or the ctx is done
+	Wait bool
 }
 
 // Topic is a structure which encapsulates the Topic of pubsub instance
@@ -37,6 +76,33 @@ type Subscription struct {
 	pubsub.Subscription
 }
 
+// SubscriptionInfo is a subscription with its backlog
+type SubscriptionInfo struct {
+	Subscription
+	// Head is the offset of the latest message of the topic, nil if the topic is empty
+	Head *pubsub.Offset
+	// Unacked is the number of messages which are not acked, it is the sum of
+	// Outstanding and Undelivered
+	Unacked int64
+	// Outstanding is the number of messages sent but not acked
+	Outstanding int64
+	// Undelivered is the number of messages which have not been sent
+	Undelivered int64
+	// OldestUnacked is the offset of the earliest unacked message
+	OldestUnacked *pubsub.Offset
+	// OldestUnackedAge is how long the earliest unacked message has waited
+	OldestUnackedAge time.Duration
+	// Truncated is true if the backlog is too large to be counted, the counts
+	// are lower bounds then
+	Truncated bool
+}
+
+// PushSubscription is a subscription with a push endpoint and the topic it belongs to
+type PushSubscription struct {
+	Topic string
+	Subscription
+}
+
 // Snapshot is a structure which encapsulates the Snapshot of pubsub instance
 type Snapshot struct {
 	pubsub.Snapshot
@@ -44,8 +110,29 @@ type Snapshot struct {
 
 // Message is an encapsulation of message information
 type Message struct {
-	Payload []byte
-	ID      string
+	Payload    []byte
+	Attributes map[string]string `json:",omitempty"`
+	// PublishTime is the time in unix nanoseconds when the message is published
+	PublishTime int64
+	ID          string
+	// DeliveryAttempt is the number of times the message has been delivered to the subscription
+	DeliveryAttempt int
+	// DedupKey is given by the producer, a message whose key has been published
+	// within the dedup window is not written again
+	DedupKey string `json:",omitempty"`
+	// OrderingKey groups the messages which are delivered in order by an ordered subscription
+	OrderingKey string `json:",omitempty"`
+	// DeliverAt is the time in unix nanoseconds before which the message is invisible
+	// to the subscriptions, at most one of DeliverAt and Delay can be set
+	DeliverAt int64 `json:",omitempty"`
+	// Delay is the duration after publishing before the message is visible
+	Delay time.Duration `json:",omitempty"`
+	// TTL is the duration after the message is visible before it expires, the TTL
+	// of the topic is used if it is 0. An expired message is acked without being delivered
+	TTL time.Duration `json:",omitempty"`
+	// ScheduleID is the id returned when a delayed message is published, the message
+	// gets a new ID when it is due and appended to the topic
+	ScheduleID string `json:",omitempty"`
 }
 
 // NewTips returns a tips object
@@ -55,7 +142,9 @@ func NewTips(path string) (tips *Tips, err error) {
 		return nil, err
 	}
 	return &Tips{
-		ps: ps,
+		ps:          ps,
+		notifier:    newNotifier(ps),
+		dedupWindow: DefaultDedupWindow,
 	}, nil
 }
 
@@ -66,10 +155,60 @@ func MockTips() (*Tips, error) {
 		return nil, err
 	}
 	return &Tips{
-		ps: ps,
+		ps:          ps,
+		notifier:    newNotifier(ps),
+		dedupWindow: DefaultDedupWindow,
 	}, nil
 }
 
+// StartGC starts collecting the garbage of deleted topics in background until the ctx is done
+func (ti *Tips) StartGC(ctx context.Context, interval time.Duration, batchSize int) {
+	go pubsub.NewGC(ti.ps, interval, batchSize).Run(ctx)
+}
+
+// StartTrimmer starts trimming messages out of the retention of topics and the expired
+// dedup entries in background until the ctx is done
+func (ti *Tips) StartTrimmer(ctx context.Context, interval time.Duration, batchSize int) {
+	tr := pubsub.NewTrimmer(ti.ps, interval, batchSize, ti.dedupWindow)
+	tr.SetClock(ti.now)
+	go tr.Run(ctx)
+}
+
+// SetDedupWindow sets the window within which the messages published with the same
+// dedup key are written only once, 0 disables the deduplication. It should be called
+// before publishing and starting the trimmer
+func (ti *Tips) SetDedupWindow(window time.Duration) {
+	ti.dedupWindow = window
+}
+
+// StartNotifier starts notifying the messages published on other nodes to the waiters
+// of this process, the versions of the watched topics are checked every interval
+func (ti *Tips) StartNotifier(ctx context.Context, interval time.Duration) {
+	ti.notifier.start(ctx, interval)
+}
+
+// SetNodeID sets the id of this node in the versions of the topics it publishes to,
+// it should be stable across restarts and unique among the nodes. A random one is
+// used if it is not set. It should be called before starting the notifier.
+func (ti *Tips) SetNodeID(id string) {
+	ti.notifier.node = []byte(id)
+}
+
+// Watch returns a watcher which is notified when messages are published to a topic
+func (ti *Tips) Watch(ctx context.Context, topic string) (*Watcher, error) {
+	t, err := ti.Topic(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	return ti.notifier.watch(&t.Topic), nil
+}
+
+// Migrate rewrites all the stored values in the legacy JSON format to the binary format,
+// returns the number of migrated values
+func (ti *Tips) Migrate(ctx context.Context, batchSize int) (int, error) {
+	return ti.ps.Migrate(ctx, batchSize)
+}
+
 // CreateTopic creates a Topic object
 func (ti *Tips) CreateTopic(ctx context.Context, topic string) (*Topic, error) {
 	txn, err := ti.ps.Begin()
@@ -90,6 +229,51 @@ func (ti *Tips) CreateTopic(ctx context.Context, topic string) (*Topic, error) {
 
 }
 
+// CreatePartitionedTopic creates a topic whose messages are spread over the partitions,
+// the partitions of an existing topic can not be changed, so the topic is returned only
+// if it has the same partitions. 0 and 1 partition are the same, the topic is not partitioned
+func (ti *Tips) CreatePartitionedTopic(ctx context.Context, topic string, partitions int) (*Topic, error) {
+	if partitions < 0 || partitions > pubsub.MaxPartitions {
+		return nil, invalidArgument("partitions should be between 0 and %d", pubsub.MaxPartitions)
+	}
+	// A topic with a single partition is not partitioned
+	if partitions <= 1 {
+		partitions = 0
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+
+	t, err := txn.GetTopic(topic)
+	if err != nil && err != pubsub.ErrNotFound {
+		return nil, err
+	}
+	if err == nil {
+		existing := 0
+		if t.Partitioned() {
+			existing = t.Partitions
+		}
+		if existing != partitions {
+			return nil, invalidArgument("topic has %d partitions", t.Partitions)
+		}
+	}
+	if err == pubsub.ErrNotFound {
+		if t, err = txn.CreateTopic(topic); err != nil {
+			return nil, err
+		}
+		t.Partitions = partitions
+		if err = txn.UpdateTopic(t); err != nil {
+			return nil, err
+		}
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Topic{Topic: *t}, nil
+}
+
 // Topic returns a topic queried by name
 func (ti *Tips) Topic(ctx context.Context, name string) (*Topic, error) {
 	txn, err := ti.ps.Begin()
@@ -100,13 +284,69 @@ func (ti *Tips) Topic(ctx context.Context, name string) (*Topic, error) {
 
 	t, err := txn.GetTopic(name)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Topic{Topic: *t}, nil
+}
+
+// SetRetention sets the retention policy of a topic, a nil retention keeps messages forever
+func (ti *Tips) SetRetention(ctx context.Context, topic string, retention *pubsub.Retention) (*Topic, error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Retention = retention
+	if err = txn.UpdateTopic(t); err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Topic{Topic: *t}, nil
+}
+
+// SetTTL sets the time to live of the messages published to a topic without one,
+// 0 means they never expire. It only applies to the messages published later
+func (ti *Tips) SetTTL(ctx context.Context, topic string, ttl time.Duration) (*Topic, error) {
+	if ttl < 0 {
+		return nil, invalidArgument("ttl should not be negative")
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
 	}
+	defer rollback(txn, err)
 
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	t.TTL = ttl
+	if err = txn.UpdateTopic(t); err != nil {
+		return nil, err
+	}
 	if err = txn.Commit(ctx); err != nil {
 		return nil, err
 	}
@@ -120,7 +360,11 @@ func (ti *Tips) Destroy(ctx context.Context, topic string) error {
 		return err
 	}
 	defer rollback(txn, err)
-	if err = txn.DeleteTopic(topic); err != nil {
+	err = txn.DeleteTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return notFound("topic")
+	}
+	if err != nil {
 		return err
 	}
 	if err = txn.Commit(ctx); err != nil {
@@ -133,6 +377,22 @@ func (ti *Tips) Destroy(ctx context.Context, topic string) error {
 // The topic and msgs which are the input parameters shouldn't be empty
 // Note that the messages returned should be in the same order as the messages to be published.
 func (ti *Tips) Publish(ctx context.Context, msg []string, topic string) ([]string, error) {
+	messages := make([]*Message, len(msg))
+	for i := range msg {
+		messages[i] = &Message{Payload: []byte(msg[i])}
+	}
+	return ti.PublishMessages(ctx, messages, topic)
+}
+
+// PublishMessages publishes messages with their attributes to a topic
+// The ID, PublishTime, DeliveryAttempt and ScheduleID of the messages are ignored
+// and the message ids are returned in the same order as the messages.
+// A message whose DedupKey has been published within the dedup window is not
+// written again, the id of the original message is returned instead.
+// A message which is not due yet is kept in the schedule until the scheduler
+// appends it to the topic, it gets a new id then and the returned one is its
+// ScheduleID when it is pulled.
+func (ti *Tips) PublishMessages(ctx context.Context, msg []*Message, topic string) ([]string, error) {
 	txn, err := ti.ps.Begin()
 	if err != nil {
 		return nil, err
@@ -141,85 +401,602 @@ func (ti *Tips) Publish(ctx context.Context, msg []string, topic string) ([]stri
 
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	now := ti.now()
+	MessageID := make([]string, len(msg))
+	// The indexes of the messages to be appended, the delayed ones and the ones
+	// repeating them in the batch
+	var appended, scheduled []int
+	repeated := make(map[int]int)
+	batch := make(map[string]int)
+	var message, delayed []*pubsub.Message
+	for i := range msg {
+		deliverAt, err := msg[i].deliverAt(now)
+		if err != nil {
+			return nil, err
+		}
+		expireAt, err := msg[i].expireAt(t, now, deliverAt)
+		if err != nil {
+			return nil, err
+		}
+		if key := msg[i].DedupKey; key != "" && ti.dedupWindow > 0 {
+			if j, ok := batch[key]; ok {
+				repeated[i] = j
+				continue
+			}
+			offset, err := txn.GetDedup(t, key, now, ti.dedupWindow)
+			if err != nil && err != pubsub.ErrNotFound {
+				return nil, err
+			}
+			if err == nil {
+				MessageID[i] = pubsub.MessageID{Offset: offset}.String()
+				continue
+			}
+			batch[key] = i
+		}
+		m := &pubsub.Message{
+			Payload:     msg[i].Payload,
+			Attributes:  msg[i].Attributes,
+			OrderingKey: msg[i].OrderingKey,
+			DeliverAt:   deliverAt,
+			ExpireAt:    expireAt,
+		}
+		if deliverAt > 0 {
+			scheduled = append(scheduled, i)
+			delayed = append(delayed, m)
+			continue
+		}
+		appended = append(appended, i)
+		message = append(message, m)
+	}
+	var messageID []pubsub.MessageID
+	if len(message) > 0 {
+		if messageID, err = txn.Append(t, now, message...); err != nil {
+			return nil, err
+		}
+	}
+	if len(delayed) > 0 {
+		// The indexes of a partitioned topic are sparse, so the delayed ones follow the last
+		first := 0
+		if len(messageID) > 0 {
+			first = int(messageID[len(messageID)-1].Index) + 1
+		}
+		scheduleID, err := txn.Schedule(t, now, first, delayed...)
+		if err != nil {
+			return nil, err
+		}
+		messageID = append(messageID, scheduleID...)
+	}
+	for k, i := range append(appended, scheduled...) {
+		MessageID[i] = messageID[k].String()
+		if key := msg[i].DedupKey; key != "" && ti.dedupWindow > 0 {
+			if err := txn.SetDedup(t, key, messageID[k].Offset, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, j := range repeated {
+		MessageID[i] = MessageID[j]
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if len(message) > 0 {
+		ti.notifier.published(t)
+	}
+	return MessageID, nil
+}
+
+// expireAt returns the time in unix nanoseconds when a message expires, 0 if it never
+// expires. The TTL of a delayed message starts when it is due
+func (m *Message) expireAt(t *pubsub.Topic, now time.Time, deliverAt int64) (int64, error) {
+	if m.TTL < 0 {
+		return 0, invalidArgument("ttl should not be negative")
+	}
+	ttl := m.TTL
+	if ttl == 0 {
+		ttl = t.TTL
+	}
+	if ttl == 0 {
+		return 0, nil
+	}
+	if deliverAt > 0 {
+		return deliverAt + int64(ttl), nil
+	}
+	return now.Add(ttl).UnixNano(), nil
+}
+
+// Ack acknowledges a message, only the message itself is acked and
+// acking a message which has been acked is a no-op
+func (ti *Tips) Ack(ctx context.Context, msgid string, topic string, subName string) (err error) {
+	mu := ti.locks.get(topic, subName)
+	mu.Lock()
+	defer mu.Unlock()
+	return ti.retry(ctx, func() error {
+		return ti.ack(ctx, msgid, topic, subName)
+	})
+}
+
+func (ti *Tips) ack(ctx context.Context, msgid string, topic string, subName string) (err error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return notFound("topic")
+	}
+	if err != nil {
+		return err
+	}
+	s, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return notFound("subname")
+	}
+	if err != nil {
+		return err
+	}
+	offset, err := pubsub.OffsetFromString(msgid)
+	if err != nil {
+		return err
+	}
+	if _, err = txn.GetLease(t, s, offset); err == pubsub.ErrNotFound {
+		// Acked already or never sent
+		return txn.Commit(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if err = txn.Release(t, s, offset); err != nil {
+		return err
+	}
+	if err = txn.AdvanceAcked(t, s); err != nil {
+		return err
+	}
+	err = txn.UpdateSubscription(t, s)
+	if err != nil {
+		return err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+
+}
+
+// ModifyAckDeadline resets the ack deadline of a message which has been pulled but not acked,
+// the message is redelivered after deadline from now if it is still not acked
+func (ti *Tips) ModifyAckDeadline(ctx context.Context, msgid string, topic string, subName string, deadline time.Duration) error {
+	mu := ti.locks.get(topic, subName)
+	mu.Lock()
+	defer mu.Unlock()
+	return ti.retry(ctx, func() error {
+		return ti.modifyAckDeadline(ctx, msgid, topic, subName, deadline)
+	})
+}
+
+func (ti *Tips) modifyAckDeadline(ctx context.Context, msgid string, topic string, subName string, deadline time.Duration) error {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return notFound("topic")
+	}
+	if err != nil {
+		return err
+	}
+	s, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return notFound("subname")
+	}
+	if err != nil {
+		return err
+	}
+	offset, err := pubsub.OffsetFromString(msgid)
+	if err != nil {
+		return err
+	}
+	lease, err := txn.GetLease(t, s, offset)
+	if err == pubsub.ErrNotFound {
+		return notFound("message")
+	}
+	if err != nil {
+		return err
+	}
+	lease.Deadline = ti.now().Add(deadline).UnixNano()
+	if err = txn.Lease(t, s, offset, lease); err != nil {
+		return err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return err
+	}
+	// The waiters sleep until the earliest deadline, wake them up as the message
+	// may be redelivered earlier now
+	ti.notifier.published(t)
+	return nil
+}
+
+// Nack tells that a pulled message can not be processed now, it is redelivered on the next pull
+func (ti *Tips) Nack(ctx context.Context, msgid string, topic string, subName string) error {
+	return ti.ModifyAckDeadline(ctx, msgid, topic, subName, 0)
+}
+
+// StartPosition is where a new subscription starts to receive messages, at
+// most one of the fields is set and it starts from the latest message if none
+// is set
+type StartPosition struct {
+	// Earliest starts from the earliest message retained by the topic
+	Earliest bool
+	// Time starts from the messages published at or after it
+	Time time.Time
+	// Offset starts from the messages after it
+	Offset *pubsub.Offset
+	// Snapshot starts from a snapshot of SnapshotSubscription of the topic
+	Snapshot             string
+	SnapshotSubscription string
+}
+
+// Validate checks that at most one position is set
+func (p *StartPosition) Validate() error {
+	n := 0
+	if p.Earliest {
+		n++
+	}
+	if !p.Time.IsZero() {
+		n++
+	}
+	if p.Offset != nil {
+		n++
+	}
+	if p.Snapshot != "" {
+		if p.SnapshotSubscription == "" {
+			return invalidArgument("the subscription of the snapshot should be given")
+		}
+		n++
+	}
+	if n > 1 {
+		return invalidArgument("at most one start position should be given")
+	}
+	return nil
+}
+
+// offset returns the offset which the Sent and Acked of a new subscription are
+// set to, nil means the latest
+func (p *StartPosition) offset(txn *pubsub.Transaction, t *pubsub.Topic) (*pubsub.Offset, error) {
+	switch {
+	case p.Earliest:
+		// The offset right before the first message, its index may be -1, which is
+		// still ordered by Cmp and never sought directly but only after Next
+		var earliest *pubsub.Offset
+		if err := txn.Scan(t, &pubsub.Offset{}, func(id pubsub.MessageID, message *pubsub.Message) bool {
+			earliest = id.Offset.Prev()
+			return false
+		}); err != nil {
+			return nil, err
+		}
+		return earliest, nil
+	case !p.Time.IsZero():
+		return pubsub.OffsetFromTime(p.Time), nil
+	case p.Offset != nil:
+		return p.Offset, nil
+	case p.Snapshot != "":
+		s, err := txn.GetSubscription(t, p.SnapshotSubscription)
+		if err == pubsub.ErrNotFound {
+			return nil, notFound("subname")
+		}
+		if err != nil {
+			return nil, err
+		}
+		snap, err := txn.GetSnapshot(t, s, p.Snapshot)
+		if err == pubsub.ErrNotFound {
+			return nil, notFound("snapshot")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return snap.Subscription.Acked, nil
+	}
+	return nil, nil
+}
+
+// Subscribe associates a topic with a subscription.
+// A new subscription starts from the start position, or the latest message if
+// it is nil. The start position is ignored if the subscription exists.
+func (ti *Tips) Subscribe(ctx context.Context, subName string, topic string, start *StartPosition) (*Subscription, error) {
+	return ti.SubscribeWithOptions(ctx, subName, topic, &SubscribeOptions{Start: start})
+}
+
+// SubscribeOptions are the settings of a subscription, the nil ones are not changed
+type SubscribeOptions struct {
+	// Start is where the subscription starts, it is used only if the subscription is created
+	Start *StartPosition
+	// DeadLetterTopic receives the messages which have been delivered MaxDeliveryAttempts
+	// times but not acked, an empty one disables it
+	DeadLetterTopic     *string
+	MaxDeliveryAttempts int
+	// Filter replaces the filter, an empty one delivers all messages
+	Filter *string
+	// PushEndpoint replaces the push endpoint, an empty one makes it a pull subscription
+	PushEndpoint *string
+	// Ordered enables or disables the ordered delivery
+	Ordered *bool
+}
+
+// Validate checks the options of a subscription of the topic
+func (o *SubscribeOptions) Validate(topic string) error {
+	if o.Start != nil {
+		if err := o.Start.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.DeadLetterTopic != nil && *o.DeadLetterTopic != "" {
+		if o.MaxDeliveryAttempts <= 0 {
+			return invalidArgument("max delivery attempts should be greater than 0")
+		}
+		if *o.DeadLetterTopic == topic {
+			return invalidArgument("dead letter topic should not be the topic itself")
+		}
+	}
+	if o.Filter != nil {
+		if _, err := ParseFilter(*o.Filter); err != nil {
+			return invalidArgument("%s", err)
+		}
+	}
+	if o.PushEndpoint != nil {
+		return ValidatePushEndpoint(*o.PushEndpoint)
+	}
+	return nil
+}
+
+// changes returns true if the options change a subscription which exists
+func (o *SubscribeOptions) changes() bool {
+	return o.DeadLetterTopic != nil || o.Filter != nil || o.PushEndpoint != nil || o.Ordered != nil
+}
+
+// apply sets the options on a subscription in the transaction
+func (o *SubscribeOptions) apply(txn *pubsub.Transaction, s *pubsub.Subscription) error {
+	if o.DeadLetterTopic != nil {
+		s.DeadLetterTopic, s.MaxDeliveryAttempts = *o.DeadLetterTopic, 0
+		if *o.DeadLetterTopic != "" {
+			_, err := txn.GetTopic(*o.DeadLetterTopic)
+			if err == pubsub.ErrNotFound {
+				return notFound("dead letter topic")
+			}
+			if err != nil {
+				return err
+			}
+			s.MaxDeliveryAttempts = o.MaxDeliveryAttempts
+		}
+	}
+	if o.Filter != nil {
+		s.Filter = *o.Filter
+	}
+	if o.PushEndpoint != nil {
+		s.PushEndpoint = *o.PushEndpoint
+	}
+	if o.Ordered != nil {
+		s.Ordered = *o.Ordered
+	}
+	return nil
+}
+
+// SubscribeWithOptions associates a topic with a subscription like Subscribe, and sets
+// the options of the subscription in the same transaction, so that it is never seen
+// without them
+func (ti *Tips) SubscribeWithOptions(ctx context.Context, subName string, topic string, opts *SubscribeOptions) (*Subscription, error) {
+	if err := opts.Validate(topic); err != nil {
+		return nil, err
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := txn.GetSubscription(t, subName)
+	if err != nil && err != pubsub.ErrNotFound {
+		return nil, err
+	}
+	changed := opts.changes()
+	if err == pubsub.ErrNotFound {
+		if s, err = txn.CreateSubscription(t, subName); err != nil {
+			return nil, err
+		}
+		if opts.Start != nil {
+			offset, err := opts.Start.offset(txn, t)
+			if err != nil {
+				return nil, err
+			}
+			if offset != nil {
+				s.Sent, s.Acked = offset, offset
+				changed = true
+			}
+		}
+	}
+	if changed {
+		if err = opts.apply(txn, s); err != nil {
+			return nil, err
+		}
+		if err = txn.UpdateSubscription(t, s); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Subscription{Subscription: *s}, nil
+}
+
+// update sets the options of a subscription which exists in a transaction
+func (ti *Tips) update(ctx context.Context, subName string, topic string, opts *SubscribeOptions) (*Subscription, error) {
+	if err := opts.Validate(topic); err != nil {
+		return nil, err
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("subname")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = opts.apply(txn, s); err != nil {
+		return nil, err
+	}
+	if err = txn.UpdateSubscription(t, s); err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Subscription{Subscription: *s}, nil
+}
+
+// Subscription returns a subscription with its backlog, the backlog is counted by
+// scanning at most maxBacklogScan messages
+func (ti *Tips) Subscription(ctx context.Context, subName string, topic string) (*SubscriptionInfo, error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
+	if err != nil {
+		return nil, err
+	}
+	sub, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("subname")
 	}
-
 	if err != nil {
 		return nil, err
 	}
-	message := make([]*pubsub.Message, len(msg))
-	for i := range msg {
-		message[i] = &pubsub.Message{
-			Payload: []byte(msg[i]),
-		}
+	b, err := txn.GetBacklog(t, sub, maxBacklogScan)
+	if err != nil {
+		return nil, err
 	}
-	messageID, err := txn.Append(t, message...)
+	head, err := txn.Head(t)
 	if err != nil {
 		return nil, err
 	}
 	if err = txn.Commit(ctx); err != nil {
 		return nil, err
 	}
-	MessageID := make([]string, len(messageID))
-	for i := range messageID {
-		MessageID[i] = messageID[i].String()
-	}
 
-	return MessageID, nil
+	info := &SubscriptionInfo{
+		Subscription:     Subscription{Subscription: *sub},
+		Head:             head,
+		Unacked:          b.Outstanding + b.Undelivered,
+		Outstanding:      b.Outstanding,
+		Undelivered:      b.Undelivered,
+		OldestUnacked:    b.Oldest,
+		OldestUnackedAge: b.OldestAge(ti.now()),
+		Truncated:        b.Truncated,
+	}
+	return info, nil
 }
 
-// Ack acknowledges a message
-func (ti *Tips) Ack(ctx context.Context, msgid string, topic string, subName string) (err error) {
-	txn, err := ti.ps.Begin()
-	if err != nil {
-		return err
-	}
-	defer rollback(txn, err)
-	t, err := txn.GetTopic(topic)
-	if err != nil {
-		return err
-	}
-	s, err := txn.GetSubscription(t, subName)
-	if err != nil {
-		return err
+// SetDeadLetter moves messages of a subscription which have been delivered maxDeliveryAttempts
+// times but not acked to the deadLetterTopic, an empty deadLetterTopic disables it
+func (ti *Tips) SetDeadLetter(ctx context.Context, subName string, topic string, deadLetterTopic string, maxDeliveryAttempts int) (*Subscription, error) {
+	return ti.update(ctx, subName, topic, &SubscribeOptions{DeadLetterTopic: &deadLetterTopic, MaxDeliveryAttempts: maxDeliveryAttempts})
+}
+
+// SetFilter sets the filter of a subscription, only the messages matching the filter
+// are delivered, an empty filter delivers all messages
+func (ti *Tips) SetFilter(ctx context.Context, subName string, topic string, filter string) (*Subscription, error) {
+	return ti.update(ctx, subName, topic, &SubscribeOptions{Filter: &filter})
+}
+
+// SetOrdered enables or disables the ordered delivery of a subscription, the messages
+// with the same ordering key are delivered one by one once it is enabled, a message is
+// not delivered until the earlier ones with the key are acked
+func (ti *Tips) SetOrdered(ctx context.Context, subName string, topic string, ordered bool) (*Subscription, error) {
+	return ti.update(ctx, subName, topic, &SubscribeOptions{Ordered: &ordered})
+}
+
+// SetPushEndpoint sets the endpoint which the messages of a subscription are posted to,
+// an empty endpoint makes it a pull subscription
+func (ti *Tips) SetPushEndpoint(ctx context.Context, subName string, topic string, endpoint string) (*Subscription, error) {
+	return ti.update(ctx, subName, topic, &SubscribeOptions{PushEndpoint: &endpoint})
+}
+
+// ValidatePushEndpoint returns an error if the endpoint is not an absolute http or https URL,
+// an empty endpoint is valid
+func ValidatePushEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return nil
 	}
-	s.Acked = pubsub.OffsetFromString(msgid)
-	err = txn.UpdateSubscription(t, s)
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return err
+		return invalidArgument("invalid push endpoint, %s", err)
 	}
-	if err = txn.Commit(ctx); err != nil {
-		return err
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalidArgument("invalid push endpoint, it should be an absolute http or https URL")
 	}
 	return nil
-
 }
 
-// Subscribe associates a topic with a subscription.
-func (ti *Tips) Subscribe(ctx context.Context, subName string, topic string) (*Subscription, error) {
+// PushSubscriptions lists the subscriptions of all topics which have a push endpoint
+func (ti *Tips) PushSubscriptions(ctx context.Context) ([]*PushSubscription, error) {
 	txn, err := ti.ps.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer rollback(txn, err)
-	t, err := txn.GetTopic(topic)
-	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
-	}
-
+	topics, err := txn.GetTopics()
 	if err != nil {
 		return nil, err
 	}
-
-	s, err := txn.CreateSubscription(t, subName)
-	if err != nil {
-		return nil, err
+	var subs []*PushSubscription
+	for _, t := range topics {
+		ss, err := txn.GetSubscriptions(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ss {
+			if s.PushEndpoint != "" {
+				subs = append(subs, &PushSubscription{Topic: t.Name, Subscription: Subscription{Subscription: *s}})
+			}
+		}
 	}
-
 	if err = txn.Commit(ctx); err != nil {
 		return nil, err
 	}
-	return &Subscription{Subscription: *s}, nil
+	return subs, nil
 }
 
 // Unsubscribe unsubscribes a topic and delete the subscription
@@ -231,7 +1008,7 @@ func (ti *Tips) Unsubscribe(ctx context.Context, subName string, topic string) e
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return fmt.Errorf(ErrNotFound, "topic")
+		return notFound("topic")
 	}
 	if err != nil {
 		return err
@@ -249,7 +1026,77 @@ func (ti *Tips) Unsubscribe(ctx context.Context, subName string, topic string) e
 
 // Pull pulls messages of a specified topic according to the pull request
 // Returns messages required by the pull request.
+// Concurrent pullers of a subscription receive disjoint messages, pulls in the
+// same process are serialized and the conflicts across processes are retried.
 func (ti *Tips) Pull(ctx context.Context, req *PullReq) ([]*Message, error) {
+	if !req.Wait {
+		messages, _, err := ti.TryPull(ctx, req)
+		return messages, err
+	}
+
+	w, err := ti.Watch(ctx, req.Topic)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	for {
+		published := w.C()
+		messages, next, err := ti.TryPull(ctx, req)
+		if err != nil || len(messages) > 0 {
+			return messages, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-published:
+		case <-ti.RedeliveryAfter(next):
+		}
+	}
+}
+
+// TryPull pulls messages without waiting, and returns the time when the earliest lease
+// of the subscription not expired expires as well, including the ones of the pulled
+// messages, zero if there is none. No message can be redelivered before then if
+// nothing is published or nacked.
+func (ti *Tips) TryPull(ctx context.Context, req *PullReq) ([]*Message, time.Time, error) {
+	mu := ti.locks.get(req.Topic, req.SubName)
+	mu.Lock()
+	defer mu.Unlock()
+
+	var messages []*Message
+	var next time.Time
+	err := ti.retry(ctx, func() error {
+		var err error
+		messages, err = ti.pull(ctx, req, &next)
+		return err
+	})
+	return messages, next, err
+}
+
+// RedeliveryAfter returns a channel which fires when a waiting pull should pull again,
+// next is the time returned by TryPull. A nil channel is returned if there is no lease
+// to wait for, then only the publishing which is notified brings new messages. The
+// waiting is bounded by redeliveryInterval if the notifier is not started, because
+// the publishing on other nodes is not notified then.
+func (ti *Tips) RedeliveryAfter(next time.Time) <-chan time.Time {
+	watching := ti.notifier.watching()
+	if next.IsZero() {
+		if watching {
+			return nil
+		}
+		return time.After(redeliveryInterval)
+	}
+	d := next.Sub(ti.now())
+	if !watching && d > redeliveryInterval {
+		d = redeliveryInterval
+	}
+	return time.After(d)
+}
+
+// pull pulls messages in a transaction, next is set to the time when the earliest lease
+// not expired expires, including the ones of the pulled messages
+func (ti *Tips) pull(ctx context.Context, req *PullReq, next *time.Time) ([]*Message, error) {
+	*next = time.Time{}
 	var messages []*Message
 	txn, err := ti.ps.Begin()
 	if err != nil {
@@ -258,7 +1105,7 @@ func (ti *Tips) Pull(ctx context.Context, req *PullReq) ([]*Message, error) {
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(req.Topic)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
 	}
 
 	if err != nil {
@@ -267,49 +1114,269 @@ func (ti *Tips) Pull(ctx context.Context, req *PullReq) ([]*Message, error) {
 	sub, err := txn.GetSubscription(t, req.SubName)
 
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "subname")
+		return nil, notFound("subname")
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
+	filter, err := ParseFilter(sub.Filter)
 	if err != nil {
 		return nil, err
 	}
 
+	now := ti.now()
+	ackDeadline := req.AckDeadline
+	if ackDeadline <= 0 {
+		ackDeadline = DefaultAckDeadline
+	}
+
+	// leased moves next to the deadline of a lease if it is earlier
+	leased := func(deadline time.Time) {
+		if next.IsZero() || deadline.Before(*next) {
+			*next = deadline
+		}
+	}
+
+	// Redeliver the messages whose lease has expired
+	type expiredLease struct {
+		offset *pubsub.Offset
+		lease  *pubsub.Lease
+	}
+	var expired []expiredLease
+	// The ordering keys of the unacked messages, the later messages with the same
+	// keys are held back by an ordered subscription
+	blocked := make(map[string]bool)
+	// skipped is the number of leases scanned after the limit, truncated is true if
+	// there are more than maxFiltered ones, the keys of the others are not known then
+	skipped := 0
+	truncated := false
+	if err = txn.ScanLeases(t, sub, func(offset *pubsub.Offset, lease *pubsub.Lease) bool {
+		if int64(len(expired)) >= req.Limit {
+			// The leases after it are not seen, pull again at once if none is delivered
+			*next = now
+			if !sub.Ordered {
+				return false
+			}
+			// Go on collecting the keys which hold back the new messages
+			if skipped++; skipped > maxFiltered {
+				truncated = true
+				return false
+			}
+			if lease.OrderingKey != "" {
+				blocked[lease.OrderingKey] = true
+			}
+			return true
+		}
+		if key := lease.OrderingKey; sub.Ordered && key != "" {
+			// Only the earliest unacked message of a key can be delivered
+			held := blocked[key]
+			blocked[key] = true
+			if held {
+				return true
+			}
+		}
+		if lease.Expired(now) {
+			expired = append(expired, expiredLease{offset, lease})
+		} else {
+			leased(time.Unix(0, lease.Deadline))
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	var dlt *pubsub.Topic
+	if sub.DeadLetterTopic != "" && len(expired) > 0 {
+		dlt, err = txn.GetTopic(sub.DeadLetterTopic)
+		if err != nil && err != pubsub.ErrNotFound {
+			return nil, err
+		}
+		if err == pubsub.ErrNotFound {
+			zap.L().Warn("dead letter topic not found", zap.String("topic", req.Topic),
+				zap.String("subscription", req.SubName), zap.String("deadletter", sub.DeadLetterTopic))
+		}
+	}
+	var deadLetters []*pubsub.Message
+	// The number of expired messages acked without being delivered
+	dropped := 0
+	for _, e := range expired {
+		message, err := txn.GetMessage(t, e.offset)
+		if err != nil && err != pubsub.ErrNotFound {
+			return nil, err
+		}
+		// The message may have been trimmed
+		if err == pubsub.ErrNotFound {
+			if err := txn.Release(t, sub, e.offset); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if message.Expired(now) {
+			if err := txn.Release(t, sub, e.offset); err != nil {
+				return nil, err
+			}
+			dropped++
+			continue
+		}
+		// Move the message to the dead letter topic if it has been delivered too many times
+		if dlt != nil && e.lease.Attempts >= sub.MaxDeliveryAttempts {
+			if err := txn.Release(t, sub, e.offset); err != nil {
+				return nil, err
+			}
+			deadLetters = append(deadLetters, message)
+			continue
+		}
+		if req.AutoACK {
+			if err := txn.Release(t, sub, e.offset); err != nil {
+				return nil, err
+			}
+		} else {
+			lease := &pubsub.Lease{
+				Deadline:    now.Add(ackDeadline).UnixNano(),
+				Attempts:    e.lease.Attempts + 1,
+				OrderingKey: e.lease.OrderingKey,
+			}
+			if err := txn.Lease(t, sub, e.offset, lease); err != nil {
+				return nil, err
+			}
+			leased(now.Add(ackDeadline))
+		}
+		messages = append(messages, &Message{
+			Payload:         message.Payload,
+			Attributes:      message.Attributes,
+			PublishTime:     message.PublishTime,
+			ID:              e.offset.String(),
+			DeliveryAttempt: e.lease.Attempts + 1,
+			OrderingKey:     message.OrderingKey,
+			ScheduleID:      scheduleID(message),
+		})
+	}
+	if len(deadLetters) > 0 {
+		if _, err := txn.Append(dlt, now, deadLetters...); err != nil {
+			return nil, err
+		}
+	}
+
+	var last *pubsub.Offset
+	var leaseErr error
+	limit := req.Limit - int64(len(messages))
+	filtered := 0
 	scan := func(id pubsub.MessageID, message *pubsub.Message) bool {
-		if req.Limit <= 0 {
+		if limit <= 0 {
 			return false
 		}
+		// The expired messages and the ones which do not match the filter are acked
+		// without being delivered
+		if message.Expired(now) {
+			last = id.Offset
+			dropped++
+			filtered++
+			return filtered < maxFiltered
+		}
+		if !filter.Match(message.Attributes) {
+			last = id.Offset
+			filtered++
+			return filtered < maxFiltered
+		}
+		key := message.OrderingKey
+		if !sub.Ordered {
+			key = ""
+		}
+		// The message is held back by an expired lease which has never been delivered,
+		// it is delivered as a redelivery once the earlier ones with the key are acked
+		if key != "" && blocked[key] {
+			if leaseErr = txn.Lease(t, sub, id.Offset, &pubsub.Lease{OrderingKey: key}); leaseErr != nil {
+				return false
+			}
+			last = id.Offset
+			filtered++
+			return filtered < maxFiltered
+		}
+		if !req.AutoACK {
+			lease := &pubsub.Lease{Deadline: now.Add(ackDeadline).UnixNano(), Attempts: 1, OrderingKey: key}
+			if leaseErr = txn.Lease(t, sub, id.Offset, lease); leaseErr != nil {
+				return false
+			}
+			leased(now.Add(ackDeadline))
+			if key != "" {
+				blocked[key] = true
+			}
+		}
 		messages = append(messages, &Message{
-			Payload: message.Payload,
-			ID:      id.String(),
+			Payload:         message.Payload,
+			Attributes:      message.Attributes,
+			PublishTime:     message.PublishTime,
+			ID:              id.String(),
+			DeliveryAttempt: 1,
+			OrderingKey:     message.OrderingKey,
+			ScheduleID:      scheduleID(message),
 		})
-		req.Limit--
+		last = id.Offset
+		limit--
 		return true
 	}
-	begin := sub.Acked
+	begin := sub.Sent
 
 	if req.Offset != "" {
-		begin = pubsub.OffsetFromString(req.Offset)
+		if begin, err = pubsub.OffsetFromString(req.Offset); err != nil {
+			return nil, err
+		}
 	}
-	if err = txn.Scan(t, begin.Next(), scan); err != nil {
-		return nil, err
+	// A new message may be held back by a lease which is not scanned, so the new messages
+	// of an ordered subscription are pulled after the leases are acked or redelivered
+	if !truncated {
+		if err = txn.Scan(t, begin.Next(), scan); err != nil {
+			return nil, err
+		}
+	}
+	if leaseErr != nil {
+		return nil, leaseErr
 	}
 
-	if len(messages) == 0 {
-		return messages, txn.Commit(ctx)
+	if len(messages) == 0 && len(deadLetters) == 0 && last == nil {
+		if err = txn.Commit(ctx); err != nil {
+			return nil, err
+		}
+		ti.expired(dropped)
+		return messages, nil
 	}
 
-	sub.Sent = pubsub.OffsetFromString(messages[len(messages)-1].ID)
-	if req.AutoACK {
-		sub.Acked = sub.Sent
+	if last != nil && last.Cmp(sub.Sent) > 0 {
+		sub.Sent = last
+	}
+	if err = txn.AdvanceAcked(t, sub); err != nil {
+		return nil, err
+	}
+	if err = txn.UpdateSubscription(t, sub); err != nil {
+		return nil, err
 	}
-	txn.UpdateSubscription(t, sub)
 
 	if err = txn.Commit(ctx); err != nil {
 		return nil, err
 	}
+	if len(deadLetters) > 0 {
+		ti.notifier.published(dlt)
+	}
+	ti.expired(dropped)
 	return messages, nil
 }
 
+// scheduleID returns the id of a message when it was scheduled, empty if it was not delayed
+func scheduleID(m *pubsub.Message) string {
+	if m.ScheduleID == nil {
+		return ""
+	}
+	return pubsub.MessageID{Offset: m.ScheduleID}.String()
+}
+
+// expired counts the expired messages dropped by a pull
+func (ti *Tips) expired(n int) {
+	if n > 0 {
+		metrics.GetMetrics().MessagesExpiredCounterVec.WithLabelValues("pull").Add(float64(n))
+	}
+}
+
 // CreateSnapshots creates a snapshot of a specified subscription
 // Return the create snapshots Objcet
 func (ti *Tips) CreateSnapshots(ctx context.Context, SnapName string, subName string, topic string) (*Snapshot, error) {
@@ -320,14 +1387,14 @@ func (ti *Tips) CreateSnapshots(ctx context.Context, SnapName string, subName st
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
 	}
 	if err != nil {
 		return nil, err
 	}
 	sub, err := txn.GetSubscription(t, subName)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "subname")
+		return nil, notFound("subname")
 	}
 	if err != nil {
 		return nil, err
@@ -353,7 +1420,7 @@ func (ti *Tips) GetSnapshot(ctx context.Context, SnapName string, subName string
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
 	}
 
 	if err != nil {
@@ -361,14 +1428,14 @@ func (ti *Tips) GetSnapshot(ctx context.Context, SnapName string, subName string
 	}
 	sub, err := txn.GetSubscription(t, subName)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "subname")
+		return nil, notFound("subname")
 	}
 	if err != nil {
 		return nil, err
 	}
 	snap, err := txn.GetSnapshot(t, sub, SnapName)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "snap")
+		return nil, notFound("snap")
 	}
 	if err != nil {
 		return nil, err
@@ -388,14 +1455,14 @@ func (ti *Tips) DeleteSnapshots(ctx context.Context, SnapName string, subName st
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return fmt.Errorf(ErrNotFound, "topic")
+		return notFound("topic")
 	}
 	if err != nil {
 		return err
 	}
 	sub, err := txn.GetSubscription(t, subName)
 	if err == pubsub.ErrNotFound {
-		return fmt.Errorf(ErrNotFound, "subname")
+		return notFound("subname")
 	}
 	if err != nil {
 		return err
@@ -412,6 +1479,9 @@ func (ti *Tips) DeleteSnapshots(ctx context.Context, SnapName string, subName st
 
 // Seek seek a specified snapshot
 func (ti *Tips) Seek(ctx context.Context, SnapName string, subName string, topic string) (*Subscription, error) {
+	mu := ti.locks.get(topic, subName)
+	mu.Lock()
+	defer mu.Unlock()
 	txn, err := ti.ps.Begin()
 	if err != nil {
 		return nil, err
@@ -419,14 +1489,14 @@ func (ti *Tips) Seek(ctx context.Context, SnapName string, subName string, topic
 	defer rollback(txn, err)
 	t, err := txn.GetTopic(topic)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "topic")
+		return nil, notFound("topic")
 	}
 	if err != nil {
 		return nil, err
 	}
 	sub, err := txn.GetSubscription(t, subName)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "subname")
+		return nil, notFound("subname")
 	}
 	if err != nil {
 		return nil, err
@@ -434,14 +1504,18 @@ func (ti *Tips) Seek(ctx context.Context, SnapName string, subName string, topic
 
 	snap, err := txn.GetSnapshot(t, sub, SnapName)
 	if err == pubsub.ErrNotFound {
-		return nil, fmt.Errorf(ErrNotFound, "snapshot")
+		return nil, notFound("snapshot")
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	// Messages which were not acked when the snapshot was created are redelivered
+	if err = txn.ReleaseAll(t, sub); err != nil {
+		return nil, err
+	}
 	sub.Acked = snap.Subscription.Acked
-	sub.Sent = snap.Subscription.Sent
+	sub.Sent = snap.Subscription.Acked
 
 	err = txn.UpdateSubscription(t, sub)
 	if err != nil {
@@ -455,6 +1529,275 @@ func (ti *Tips) Seek(ctx context.Context, SnapName string, subName string, topic
 	return subscription, nil
 }
 
+// SeekToTime resets a subscription to a time, the messages published at or
+// after it are redelivered and the ones before it are treated as acked
+func (ti *Tips) SeekToTime(ctx context.Context, subName string, topic string, t time.Time) (*Subscription, error) {
+	return ti.seekTo(ctx, subName, topic, pubsub.OffsetFromTime(t))
+}
+
+// SeekToOffset resets a subscription to an offset, the messages after it are
+// redelivered and the ones at or before it are treated as acked
+func (ti *Tips) SeekToOffset(ctx context.Context, subName string, topic string, offset *pubsub.Offset) (*Subscription, error) {
+	return ti.seekTo(ctx, subName, topic, offset)
+}
+
+// seekTo moves the Sent and Acked of a subscription to the offset
+func (ti *Tips) seekTo(ctx context.Context, subName string, topic string, offset *pubsub.Offset) (*Subscription, error) {
+	mu := ti.locks.get(topic, subName)
+	mu.Lock()
+	defer mu.Unlock()
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("topic")
+	}
+	if err != nil {
+		return nil, err
+	}
+	sub, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return nil, notFound("subname")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The messages in flight are redelivered if they are after the offset
+	if err = txn.ReleaseAll(t, sub); err != nil {
+		return nil, err
+	}
+	sub.Acked = offset
+	sub.Sent = offset
+	if err = txn.UpdateSubscription(t, sub); err != nil {
+		return nil, err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &Subscription{Subscription: *sub}, nil
+}
+
+// Stats returns the size of at most maxTopics topics and the backlog of at most
+// maxSubscriptions of their subscriptions in total, at most maxScan messages are
+// scanned to count each of them. It is the StatsFunc of the metrics collector.
+func (ti *Tips) Stats(ctx context.Context, maxTopics int, maxSubscriptions int, maxScan int) ([]*metrics.TopicStats, error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	topics, err := txn.ListTopics("", "", maxTopics)
+	if err != nil {
+		txn.Rollback()
+		return nil, err
+	}
+	if err := txn.Rollback(); err != nil {
+		return nil, err
+	}
+
+	var stats []*metrics.TopicStats
+	for _, t := range topics {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		ts, err := ti.topicStats(t.Name, maxSubscriptions, maxScan)
+		if err == pubsub.ErrNotFound {
+			// Deleted by others
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		maxSubscriptions -= len(ts.Subscriptions)
+		stats = append(stats, ts)
+	}
+	return stats, nil
+}
+
+// topicStats counts a topic and at most maxSubscriptions of its subscriptions in a transaction
+func (ti *Tips) topicStats(name string, maxSubscriptions int, maxScan int) (*metrics.TopicStats, error) {
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer txn.Rollback()
+	t, err := txn.GetTopic(name)
+	if err != nil {
+		return nil, err
+	}
+	size, err := txn.GetSize(t, maxScan)
+	if err != nil {
+		return nil, err
+	}
+	ts := &metrics.TopicStats{Topic: t.Name, Messages: size.Messages, Bytes: size.Bytes}
+	if maxSubscriptions <= 0 {
+		return ts, nil
+	}
+	subs, err := txn.ListSubscriptions(t, "", "", maxSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := ti.now()
+	for _, s := range subs {
+		b, err := txn.GetBacklog(t, s, maxScan)
+		if err != nil {
+			return nil, err
+		}
+		ts.Subscriptions = append(ts.Subscriptions, &metrics.SubscriptionStats{
+			Name:             s.Name,
+			Backlog:          b.Outstanding + b.Undelivered,
+			OldestUnackedAge: b.OldestAge(now),
+		})
+	}
+	return ts, nil
+}
+
+// ListTopics lists the topics whose names begin with prefix in the order of names,
+// at most limit ones are returned in a page. The next page is listed with the
+// returned token, which is empty if there are no more topics.
+func (ti *Tips) ListTopics(ctx context.Context, prefix string, token string, limit int) ([]*Topic, string, error) {
+	after, limit, err := parseListReq(token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rollback(txn, err)
+	ts, err := txn.ListTopics(prefix, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(ts) > limit {
+		ts = ts[:limit]
+		next = listToken(ts[limit-1].Name)
+	}
+	topics := make([]*Topic, 0, len(ts))
+	for _, t := range ts {
+		topics = append(topics, &Topic{Topic: *t})
+	}
+	return topics, next, nil
+}
+
+// ListSubscriptions lists the subscriptions of a topic like ListTopics
+func (ti *Tips) ListSubscriptions(ctx context.Context, topic string, prefix string, token string, limit int) ([]*Subscription, string, error) {
+	after, limit, err := parseListReq(token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, "", notFound("topic")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	ss, err := txn.ListSubscriptions(t, prefix, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(ss) > limit {
+		ss = ss[:limit]
+		next = listToken(ss[limit-1].Name)
+	}
+	subs := make([]*Subscription, 0, len(ss))
+	for _, s := range ss {
+		subs = append(subs, &Subscription{Subscription: *s})
+	}
+	return subs, next, nil
+}
+
+// ListSnapshots lists the snapshots of a subscription like ListTopics
+func (ti *Tips) ListSnapshots(ctx context.Context, subName string, topic string, prefix string, token string, limit int) ([]*Snapshot, string, error) {
+	after, limit, err := parseListReq(token, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	txn, err := ti.ps.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rollback(txn, err)
+	t, err := txn.GetTopic(topic)
+	if err == pubsub.ErrNotFound {
+		return nil, "", notFound("topic")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	sub, err := txn.GetSubscription(t, subName)
+	if err == pubsub.ErrNotFound {
+		return nil, "", notFound("subname")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	ss, err := txn.ListSnapshots(t, sub, prefix, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if err = txn.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(ss) > limit {
+		ss = ss[:limit]
+		next = listToken(ss[limit-1].Name)
+	}
+	snapshots := make([]*Snapshot, 0, len(ss))
+	for _, snap := range ss {
+		snapshots = append(snapshots, &Snapshot{Snapshot: *snap})
+	}
+	return snapshots, next, nil
+}
+
+// listToken encodes the name of the last item in a page as the continuation token
+func listToken(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+// parseListReq decodes the name after which the items are listed from the token,
+// and bounds the limit within (0, MaxListLimit]
+func parseListReq(token string, limit int) (string, int, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	if token == "" {
+		return "", limit, nil
+	}
+	after, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(after) == 0 {
+		return "", 0, ErrInvalidToken
+	}
+	return string(after), limit, nil
+}
+
 // rollback the transaction
 func rollback(txn *pubsub.Transaction, err error) {
 	if err != nil {
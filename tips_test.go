@@ -4,12 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/tipsio/tips/metrics"
 	"github.com/tipsio/tips/store/pubsub"
 )
 
+func mustOffset(s string) *pubsub.Offset {
+	offset, err := pubsub.OffsetFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return offset
+}
+
+// expiredCount returns the number of the expired messages dropped by opt
+func expiredCount(t *testing.T, opt string) float64 {
+	m := &dto.Metric{}
+	assert.NoError(t, metrics.GetMetrics().MessagesExpiredCounterVec.WithLabelValues(opt).Write(m))
+	return m.GetCounter().GetValue()
+}
+
 func TestCreateTopic(t *testing.T) {
 	tips, err := MockTips()
 	if err != nil {
@@ -42,7 +61,7 @@ func TestTopic(t *testing.T) {
 
 	_, err2 := tips.Topic(context.Background(), "t2")
 
-	assert.Equal(t, err2, fmt.Errorf(ErrNotFound, "topic"))
+	assert.Equal(t, err2, notFound("topic"))
 
 	assert.Equal(t, top1.Name, t1.Name)
 	assert.Equal(t, top1.CreatedAt, t1.CreatedAt)
@@ -68,7 +87,10 @@ func TestDestroy(t *testing.T) {
 	assert.NoError(t, err)
 	_, err2 := tips.Topic(context.Background(), "t1")
 
-	assert.Equal(t, err2, fmt.Errorf(ErrNotFound, "topic"))
+	assert.Equal(t, err2, notFound("topic"))
+
+	err = tips.Destroy(context.Background(), "t1")
+	assert.Equal(t, NotFound, ErrorCode(err))
 }
 func TestPublish(t *testing.T) {
 	tips, err := MockTips()
@@ -104,7 +126,7 @@ func TestPublish(t *testing.T) {
 		return true
 	}
 	for i := 0; i < 3; i++ {
-		err := txn.Scan(&top1.Topic, pubsub.OffsetFromString(msgid[i]), scan)
+		err := txn.Scan(&top1.Topic, mustOffset(msgid[i]), scan)
 
 		assert.NoError(t, err)
 	}
@@ -120,6 +142,11 @@ func TestAck(t *testing.T) {
 	}
 	top1, err := tips.CreateTopic(context.Background(), "t1")
 	assert.NoError(t, err)
+
+	sub, err := tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, sub)
+
 	var messages []string
 
 	messages = append(messages, "hello tips1")
@@ -129,30 +156,126 @@ func TestAck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, msgid)
 
-	txn, err := tips.ps.Begin()
+	ms, err := tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t1", Limit: 3})
 	assert.NoError(t, err)
-	assert.NotNil(t, txn)
+	assert.Len(t, ms, 3)
 
-	sub, err := txn.CreateSubscription(&top1.Topic, "SubName")
-	assert.NoError(t, err)
-	assert.NotNil(t, sub)
-	txn.Commit(context.TODO())
+	acked := func() string {
+		txn, err := tips.ps.Begin()
+		assert.NoError(t, err)
+		assert.NotNil(t, txn)
+		s, err := txn.GetSubscription(&top1.Topic, "SubName")
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+		txn.Commit(context.TODO())
+		return s.Acked.String()
+	}
 
+	// Acking a message does not ack the ones before it
 	err = tips.Ack(context.Background(), msgid[2], "t1", "SubName")
 	assert.NoError(t, err)
+	assert.Equal(t, mustOffset(msgid[0]).Prev().String(), acked())
 
-	txn, err = tips.ps.Begin()
+	err = tips.Ack(context.Background(), msgid[0], "t1", "SubName")
 	assert.NoError(t, err)
-	assert.NotNil(t, txn)
+	assert.Equal(t, mustOffset(msgid[0]).String(), acked())
 
-	//If the current topic exists, the Append interface is called to store the message under the corresponding topic
-	sub2, err := txn.GetSubscription(&top1.Topic, "SubName")
+	err = tips.Ack(context.Background(), msgid[1], "t1", "SubName")
 	assert.NoError(t, err)
-	assert.NotNil(t, sub2)
-	txn.Commit(context.TODO())
+	assert.Equal(t, mustOffset(msgid[2]).String(), acked())
+
+	// Acking twice is a no-op
+	err = tips.Ack(context.Background(), msgid[1], "t1", "SubName")
+	assert.NoError(t, err)
+	assert.Equal(t, mustOffset(msgid[2]).String(), acked())
+
+	// Malformed ids are rejected rather than acking the offset 0-0
+	assert.Equal(t, ErrInvalidOffset, tips.Ack(context.Background(), "garbage", "t1", "SubName"))
+	assert.Equal(t, ErrInvalidOffset, tips.Nack(context.Background(), "1-", "t1", "SubName"))
+	_, err = tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t1", Limit: 3, Offset: "garbage"})
+	assert.Equal(t, ErrInvalidOffset, err)
+
+	// Pulling the acked messages again by an offset does not move Acked backwards
+	ms, err = tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t1", Limit: 3, Offset: mustOffset(msgid[0]).Prev().String()})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 3)
+	assert.Equal(t, mustOffset(msgid[2]).String(), acked())
+	for _, m := range ms {
+		assert.NoError(t, tips.Ack(context.Background(), m.ID, "t1", "SubName"))
+	}
+
+	// Nothing to redeliver
+	ms, err = tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t1", Limit: 3})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 0)
+}
+
+func TestRedelivery(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	msgid, err := tips.Publish(context.Background(), []string{"hello tips1", "hello tips2"}, "t1")
+	assert.NoError(t, err)
+
+	req := &PullReq{SubName: "SubName", Topic: "t1", Limit: 2, AckDeadline: time.Millisecond}
+	ms, err := tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	assert.NoError(t, tips.Ack(context.Background(), msgid[1], "t1", "SubName"))
+
+	// The unacked message is redelivered once the lease has expired
+	time.Sleep(10 * time.Millisecond)
+	req = &PullReq{SubName: "SubName", Topic: "t1", Limit: 2}
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, msgid[0], ms[0].ID)
+
+	// Not redelivered before the deadline
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 0)
+}
+
+func TestModifyAckDeadline(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	msgid, err := tips.Publish(context.Background(), []string{"hello tips1"}, "t1")
+	assert.NoError(t, err)
+
+	req := &PullReq{SubName: "SubName", Topic: "t1", Limit: 1}
+	ms, err := tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
 
-	assert.NotEqual(t, sub.Acked.String(), sub2.Acked.String())
-	assert.Equal(t, pubsub.OffsetFromString(msgid[2]).String(), sub2.Acked.String())
+	// Nack makes the message redelivered immediately
+	assert.NoError(t, tips.Nack(context.Background(), msgid[0], "t1", "SubName"))
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, msgid[0], ms[0].ID)
+
+	assert.NoError(t, tips.ModifyAckDeadline(context.Background(), msgid[0], "t1", "SubName", time.Hour))
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 0)
+
+	assert.NoError(t, tips.Ack(context.Background(), msgid[0], "t1", "SubName"))
+	err = tips.Nack(context.Background(), msgid[0], "t1", "SubName")
+	assert.Equal(t, notFound("message"), err)
 }
 
 func TestSubscribe(t *testing.T) {
@@ -167,7 +290,7 @@ func TestSubscribe(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, top1)
 
-	sub1, err := tips.Subscribe(context.Background(), "subName", "t1")
+	sub1, err := tips.Subscribe(context.Background(), "subName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub1)
 
@@ -184,7 +307,7 @@ func TestSubscribe(t *testing.T) {
 	assert.Equal(t, sub1.Sent.String(), val.Sent.String())
 	assert.Equal(t, sub1.Acked.String(), val.Acked.String())
 
-	sub2, err := tips.Subscribe(context.Background(), "subName", "t1")
+	sub2, err := tips.Subscribe(context.Background(), "subName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub2)
 
@@ -192,11 +315,65 @@ func TestSubscribe(t *testing.T) {
 	assert.Equal(t, sub2.Sent.String(), val.Sent.String())
 	assert.Equal(t, sub2.Acked.String(), val.Acked.String())
 	//Test sub already exists
-	_, err2 := tips.Subscribe(context.Background(), "subName", "t2")
+	_, err2 := tips.Subscribe(context.Background(), "subName", "t2", nil)
 
-	assert.Equal(t, err2, fmt.Errorf(ErrNotFound, "topic"))
+	assert.Equal(t, err2, notFound("topic"))
 
 }
+func TestSubscribeWithOptions(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.CreateTopic(ctx, "dlt")
+	assert.NoError(t, err)
+
+	missing, filter, ordered := "missing", `attributes.type = "order"`, true
+	_, err = tips.SubscribeWithOptions(ctx, "sub", "t1", &SubscribeOptions{
+		DeadLetterTopic:     &missing,
+		MaxDeliveryAttempts: 2,
+		Filter:              &filter,
+	})
+	assert.Equal(t, notFound("dead letter topic"), err)
+	// nothing is created if an option fails
+	_, err = tips.Subscription(ctx, "sub", "t1")
+	assert.Equal(t, notFound("subname"), err)
+
+	dlt := "dlt"
+	sub, err := tips.SubscribeWithOptions(ctx, "sub", "t1", &SubscribeOptions{
+		DeadLetterTopic:     &dlt,
+		MaxDeliveryAttempts: 2,
+		Filter:              &filter,
+		Ordered:             &ordered,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "dlt", sub.DeadLetterTopic)
+	assert.Equal(t, 2, sub.MaxDeliveryAttempts)
+	assert.Equal(t, filter, sub.Filter)
+	assert.True(t, sub.Ordered)
+
+	// the options of an existing subscription are all updated or none of them
+	empty := ""
+	_, err = tips.SubscribeWithOptions(ctx, "sub", "t1", &SubscribeOptions{
+		Filter:          &empty,
+		DeadLetterTopic: &missing, MaxDeliveryAttempts: 2,
+	})
+	assert.Equal(t, notFound("dead letter topic"), err)
+	info, err := tips.Subscription(ctx, "sub", "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, filter, info.Filter)
+	assert.Equal(t, "dlt", info.DeadLetterTopic)
+
+	// the options which are not given are kept
+	sub, err = tips.SubscribeWithOptions(ctx, "sub", "t1", &SubscribeOptions{Filter: &empty})
+	assert.NoError(t, err)
+	assert.Equal(t, "", sub.Filter)
+	assert.Equal(t, "dlt", sub.DeadLetterTopic)
+	assert.True(t, sub.Ordered)
+}
 func TestUnsubscribe(t *testing.T) {
 
 	tips, err := MockTips()
@@ -208,7 +385,7 @@ func TestUnsubscribe(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, top1)
 
-	sub1, err := tips.Subscribe(context.Background(), "subName", "t1")
+	sub1, err := tips.Subscribe(context.Background(), "subName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub1)
 
@@ -247,7 +424,7 @@ func TestPull(t *testing.T) {
 	top1, err := tips.CreateTopic(context.Background(), "t1")
 	assert.NoError(t, err)
 
-	sub, err := tips.Subscribe(context.Background(), "SubName", "t1")
+	sub, err := tips.Subscribe(context.Background(), "SubName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub)
 
@@ -279,7 +456,7 @@ func TestPull(t *testing.T) {
 		return true
 	}
 	for i := 0; i < 3; i++ {
-		err := txn.Scan(&top1.Topic, pubsub.OffsetFromString(msgid[i]), scan)
+		err := txn.Scan(&top1.Topic, mustOffset(msgid[i]), scan)
 
 		assert.NoError(t, err)
 	}
@@ -313,7 +490,7 @@ func TestCreateSnapshots(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, top1)
 
-	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1")
+	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub1)
 
@@ -350,14 +527,14 @@ func TestCreateSnapshots(t *testing.T) {
 
 	//The case for both of the topic and the subscription not being existed.
 	snap2, err2 := tips.CreateSnapshots(context.Background(), "snapName", "SubName", "t2")
-	assert.Equal(t, fmt.Errorf(ErrNotFound, "topic"), err2)
+	assert.Equal(t, notFound("topic"), err2)
 	assert.Nil(t, snap2)
 	//The case for the topic being existed while the subscription not.
 	top3, err := tips.CreateTopic(context.Background(), "t3")
 	assert.NoError(t, err)
 	assert.NotNil(t, top3)
 	snap3, err3 := tips.CreateSnapshots(context.Background(), "snapName", "SubName", "t3")
-	assert.Equal(t, fmt.Errorf(ErrNotFound, "subname"), err3)
+	assert.Equal(t, notFound("subname"), err3)
 	assert.Nil(t, snap3)
 
 }
@@ -371,7 +548,7 @@ func TestGetSnapshot(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, top1)
 
-	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1")
+	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub1)
 
@@ -388,13 +565,13 @@ func TestGetSnapshot(t *testing.T) {
 	assert.Equal(t, snap.Subscription.Sent.String(), get.Subscription.Sent.String())
 	assert.Equal(t, snap.Subscription.Acked.String(), get.Subscription.Acked.String())
 	get, err = tips.GetSnapshot(context.Background(), "snapName", "SubName", "t2")
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "topic"))
+	assert.Equal(t, err, notFound("topic"))
 	assert.Nil(t, get)
 	get, err = tips.GetSnapshot(context.Background(), "snapName", "subName", "t1")
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "subname"))
+	assert.Equal(t, err, notFound("subname"))
 	assert.Nil(t, get)
 	get, err = tips.GetSnapshot(context.Background(), "SnapName", "SubName", "t1")
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "snap"))
+	assert.Equal(t, err, notFound("snap"))
 	assert.Nil(t, get)
 
 }
@@ -408,7 +585,7 @@ func TestDeleteSnapshots(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, top1)
 
-	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1")
+	sub1, err := tips.Subscribe(context.Background(), "SubName", "t1", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub1)
 
@@ -429,14 +606,14 @@ func TestDeleteSnapshots(t *testing.T) {
 	assert.NoError(t, err)
 
 	get, err = tips.GetSnapshot(context.Background(), "snapName", "SubName", "t1")
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "snap"))
+	assert.Equal(t, err, notFound("snap"))
 	assert.Nil(t, get)
 
 	top2, err := tips.CreateTopic(context.Background(), "t2")
 	assert.NoError(t, err)
 	assert.NotNil(t, top2)
 
-	sub2, err := tips.Subscribe(context.Background(), "SubName", "t2")
+	sub2, err := tips.Subscribe(context.Background(), "SubName", "t2", nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sub2)
 
@@ -446,11 +623,11 @@ func TestDeleteSnapshots(t *testing.T) {
 	//Test for situations where topic  does not exist
 	err = tips.DeleteSnapshots(context.Background(), "snapName", "SubName", "t3")
 
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "topic"))
+	assert.Equal(t, err, notFound("topic"))
 	//Test for situations where sub does not exist
 	err = tips.DeleteSnapshots(context.Background(), "snapName", "subName", "t2")
 
-	assert.Equal(t, err, fmt.Errorf(ErrNotFound, "subname"))
+	assert.Equal(t, err, notFound("subname"))
 	//Test for situations where subname does not exist
 	err = tips.DeleteSnapshots(context.Background(), "SnapName", "SubName", "t2")
 
@@ -484,3 +661,884 @@ func TestSeek(t *testing.T) {
 	assert.Equal(t, sub.Sent.String(), sub2.Sent.String())
 	assert.Equal(t, sub.Name, sub2.Name)
 }
+
+func TestSetRetention(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+
+	retention := &pubsub.Retention{MaxAge: time.Hour, MaxCount: 100, Acked: true}
+	top, err := tips.SetRetention(context.Background(), "t1", retention)
+	assert.NoError(t, err)
+	assert.Equal(t, retention, top.Retention)
+
+	got, err := tips.Topic(context.Background(), "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, retention, got.Retention)
+
+	_, err = tips.SetRetention(context.Background(), "t2", retention)
+	assert.Equal(t, notFound("topic"), err)
+}
+
+func TestDeadLetter(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = tips.SetDeadLetter(context.Background(), "SubName", "t1", "dlt", 2)
+	assert.Equal(t, notFound("dead letter topic"), err)
+	_, err = tips.SetDeadLetter(context.Background(), "SubName", "t1", "t1", 2)
+	assert.Error(t, err)
+
+	_, err = tips.CreateTopic(context.Background(), "dlt")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "dlt", nil)
+	assert.NoError(t, err)
+	sub, err := tips.SetDeadLetter(context.Background(), "SubName", "t1", "dlt", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "dlt", sub.DeadLetterTopic)
+	assert.Equal(t, 2, sub.MaxDeliveryAttempts)
+
+	msgid, err := tips.Publish(context.Background(), []string{"poison", "hello tips"}, "t1")
+	assert.NoError(t, err)
+
+	req := &PullReq{SubName: "SubName", Topic: "t1", Limit: 1}
+	for attempt := 1; attempt <= 2; attempt++ {
+		ms, err := tips.Pull(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Len(t, ms, 1)
+		assert.Equal(t, msgid[0], ms[0].ID)
+		assert.Equal(t, attempt, ms[0].DeliveryAttempt)
+		assert.NoError(t, tips.Nack(context.Background(), msgid[0], "t1", "SubName"))
+	}
+
+	// The poison message is moved to the dead letter topic and does not block the subscription
+	ms, err := tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, msgid[1], ms[0].ID)
+	assert.Equal(t, 1, ms[0].DeliveryAttempt)
+	assert.NoError(t, tips.Ack(context.Background(), msgid[1], "t1", "SubName"))
+
+	ms, err = tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "dlt", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, "poison", string(ms[0].Payload))
+
+	txn, err := tips.ps.Begin()
+	assert.NoError(t, err)
+	top1, err := txn.GetTopic("t1")
+	assert.NoError(t, err)
+	s, err := txn.GetSubscription(top1, "SubName")
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, msgid[1], s.Acked.String())
+}
+
+func TestCompetingConsumers(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	msgs := make([]string, 200)
+	for i := range msgs {
+		msgs[i] = fmt.Sprintf("hello tips%d", i)
+	}
+	msgid, err := tips.Publish(context.Background(), msgs, "t1")
+	assert.NoError(t, err)
+
+	// Consumers share the subscription through different Tips instances as
+	// well, so that the conflicts of transactions are exercised
+	consumers := []*Tips{tips, tips, tips}
+	for i := 0; i < 3; i++ {
+		consumers = append(consumers, &Tips{ps: tips.ps, notifier: newNotifier(tips.ps)})
+	}
+
+	var mu sync.Mutex
+	delivered := make(map[string]int)
+	var wg sync.WaitGroup
+	for _, consumer := range consumers {
+		wg.Add(1)
+		go func(consumer *Tips) {
+			defer wg.Done()
+			req := &PullReq{SubName: "SubName", Topic: "t1", Limit: 7, AckDeadline: time.Hour}
+			for {
+				ms, err := consumer.Pull(context.Background(), req)
+				if !assert.NoError(t, err) || len(ms) == 0 {
+					return
+				}
+				for _, m := range ms {
+					mu.Lock()
+					delivered[m.ID]++
+					mu.Unlock()
+					assert.NoError(t, consumer.Ack(context.Background(), m.ID, "t1", "SubName"))
+				}
+			}
+		}(consumer)
+	}
+	wg.Wait()
+
+	// Every message is delivered exactly once
+	assert.Len(t, delivered, len(msgid))
+	for _, id := range msgid {
+		assert.Equal(t, 1, delivered[id])
+	}
+
+	txn, err := tips.ps.Begin()
+	assert.NoError(t, err)
+	top1, err := txn.GetTopic("t1")
+	assert.NoError(t, err)
+	s, err := txn.GetSubscription(top1, "SubName")
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, msgid[len(msgid)-1], s.Acked.String())
+}
+
+func TestPublishMessages(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = tips.PublishMessages(context.Background(), []*Message{{Payload: []byte("hello tips")}}, "t2")
+	assert.Equal(t, notFound("topic"), err)
+
+	start := time.Now().UnixNano()
+	msgs := []*Message{
+		{Payload: []byte("hello tips1"), Attributes: map[string]string{"content-type": "text/plain", "trace-id": "1"}},
+		{Payload: []byte("hello tips2")},
+	}
+	msgid, err := tips.PublishMessages(context.Background(), msgs, "t1")
+	assert.NoError(t, err)
+	assert.Len(t, msgid, 2)
+
+	ms, err := tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t1", Limit: 2, AutoACK: true})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	for i := range ms {
+		assert.Equal(t, msgid[i], ms[i].ID)
+		assert.Equal(t, msgs[i].Payload, ms[i].Payload)
+		assert.Equal(t, msgs[i].Attributes, ms[i].Attributes)
+		assert.True(t, ms[i].PublishTime >= start)
+	}
+}
+
+func TestPublishDedup(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	ids, err := tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("1"), DedupKey: "k1"},
+		{Payload: []byte("2")},
+		{Payload: []byte("1"), DedupKey: "k1"},
+	}, "t1")
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+	assert.NotEqual(t, ids[0], ids[1])
+	// Repeated in the same batch
+	assert.Equal(t, ids[0], ids[2])
+
+	// Retried after a timeout
+	retried, err := tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("1"), DedupKey: "k1"},
+		{Payload: []byte("3"), DedupKey: "k3"},
+	}, "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0], retried[0])
+	assert.NotEqual(t, ids[0], retried[1])
+
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 10, AutoACK: true})
+	assert.NoError(t, err)
+	var payloads []string
+	for _, msg := range msgs {
+		payloads = append(payloads, string(msg.Payload))
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, payloads)
+
+	// Disabled
+	tips.SetDedupWindow(0)
+	again, err := tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), DedupKey: "k1"}}, "t1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, ids[0], again[0])
+}
+
+func TestFilter(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = tips.SetFilter(context.Background(), "SubName", "t1", "attributes.type =")
+	assert.Error(t, err)
+	sub, err := tips.SetFilter(context.Background(), "SubName", "t1", `attributes.type = "order"`)
+	assert.NoError(t, err)
+	assert.Equal(t, `attributes.type = "order"`, sub.Filter)
+
+	msgs := []*Message{
+		{Payload: []byte("hello tips1"), Attributes: map[string]string{"type": "refund"}},
+		{Payload: []byte("hello tips2"), Attributes: map[string]string{"type": "order"}},
+		{Payload: []byte("hello tips3")},
+		{Payload: []byte("hello tips4"), Attributes: map[string]string{"type": "order"}},
+		{Payload: []byte("hello tips5")},
+	}
+	msgid, err := tips.PublishMessages(context.Background(), msgs, "t1")
+	assert.NoError(t, err)
+
+	req := &PullReq{SubName: "SubName", Topic: "t1", Limit: 10}
+	ms, err := tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, msgid[1], ms[0].ID)
+	assert.Equal(t, msgid[3], ms[1].ID)
+
+	// The messages which do not match are acked
+	assert.NoError(t, tips.Ack(context.Background(), msgid[1], "t1", "SubName"))
+	assert.NoError(t, tips.Ack(context.Background(), msgid[3], "t1", "SubName"))
+	txn, err := tips.ps.Begin()
+	assert.NoError(t, err)
+	top1, err := txn.GetTopic("t1")
+	assert.NoError(t, err)
+	s, err := txn.GetSubscription(top1, "SubName")
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, msgid[4], s.Acked.String())
+	assert.Equal(t, msgid[4], s.Sent.String())
+
+	// Nothing is delivered if no message matches
+	_, err = tips.Publish(context.Background(), []string{"hello tips6"}, "t1")
+	assert.NoError(t, err)
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 0)
+}
+
+func TestWaitPull(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t6")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t6", nil)
+	assert.NoError(t, err)
+
+	// Times out without messages
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = tips.Pull(ctx, &PullReq{SubName: "SubName", Topic: "t6", Limit: 1, Wait: true})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// Woken up once a message is published, long before the redelivery interval
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tips.Publish(context.Background(), []string{"hello tips"}, "t6")
+	}()
+	start := time.Now()
+	ms, err := tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t6", Limit: 1, Wait: true})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.True(t, time.Since(start) < redeliveryInterval/2)
+}
+
+func TestWaitPullRedelivery(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t6")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t6", nil)
+	assert.NoError(t, err)
+
+	// Without the notifier the waiting is bounded for the publishing on other nodes
+	assert.NotNil(t, tips.RedeliveryAfter(time.Time{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tips.StartNotifier(ctx, time.Hour)
+	// Nothing but the publishing is waited for
+	assert.Nil(t, tips.RedeliveryAfter(time.Time{}))
+
+	_, err = tips.Publish(context.Background(), []string{"hello tips"}, "t6")
+	assert.NoError(t, err)
+	ms, next, err := tips.TryPull(context.Background(), &PullReq{SubName: "SubName", Topic: "t6", Limit: 1, AckDeadline: 200 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	// The lease of the pulled message is waited for
+	assert.False(t, next.IsZero())
+	ms, next, err = tips.TryPull(context.Background(), &PullReq{SubName: "SubName", Topic: "t6", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 0)
+	assert.False(t, next.IsZero())
+
+	// Woken up at the deadline of the lease rather than polling
+	start := time.Now()
+	ms, err = tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t6", Limit: 1, Wait: true})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, 2, ms[0].DeliveryAttempt)
+	assert.True(t, time.Since(start) < redeliveryInterval/2)
+}
+
+func TestNotifier(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t7")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t7", nil)
+	assert.NoError(t, err)
+
+	// Another node sharing the same storage
+	other := &Tips{ps: tips.ps, notifier: newNotifier(tips.ps)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tips.StartNotifier(ctx, 5*time.Millisecond)
+	other.StartNotifier(ctx, 5*time.Millisecond)
+
+	w, err := other.Watch(context.Background(), "t7")
+	assert.NoError(t, err)
+	defer w.Close()
+	// Wait until the notifier has seen the topic
+	select {
+	case <-w.C():
+	case <-time.After(time.Second):
+		t.Fatal("topic is not seen by the notifier")
+	}
+
+	published := w.C()
+	_, err = tips.Publish(context.Background(), []string{"hello tips"}, "t7")
+	assert.NoError(t, err)
+	select {
+	case <-published:
+	case <-time.After(redeliveryInterval / 2):
+		t.Fatal("published messages are not notified to other nodes")
+	}
+	ms, err := other.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t7", Limit: 1, Wait: true})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+}
+
+func TestList(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range []string{"list-t1", "list-t2", "list-t3"} {
+		_, err = tips.CreateTopic(context.Background(), name)
+		assert.NoError(t, err)
+	}
+
+	topics, token, err := tips.ListTopics(context.Background(), "list-", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, topics, 2)
+	assert.Equal(t, "list-t1", topics[0].Name)
+	assert.NotEmpty(t, token)
+	topics, token, err = tips.ListTopics(context.Background(), "list-", token, 2)
+	assert.NoError(t, err)
+	assert.Len(t, topics, 1)
+	assert.Equal(t, "list-t3", topics[0].Name)
+	assert.Empty(t, token)
+	_, _, err = tips.ListTopics(context.Background(), "list-", "!", 2)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	_, _, err = tips.ListSubscriptions(context.Background(), "list-t0", "", "", 0)
+	assert.Equal(t, notFound("topic"), err)
+	for _, name := range []string{"s1", "s2"} {
+		_, err = tips.Subscribe(context.Background(), name, "list-t1", nil)
+		assert.NoError(t, err)
+	}
+	subs, token, err := tips.ListSubscriptions(context.Background(), "list-t1", "", "", 0)
+	assert.NoError(t, err)
+	assert.Len(t, subs, 2)
+	assert.Empty(t, token)
+
+	_, _, err = tips.ListSnapshots(context.Background(), "s3", "list-t1", "", "", 0)
+	assert.Equal(t, notFound("subname"), err)
+	_, err = tips.CreateSnapshots(context.Background(), "snap", "s1", "list-t1")
+	assert.NoError(t, err)
+	snapshots, token, err := tips.ListSnapshots(context.Background(), "s1", "list-t1", "", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "snap", snapshots[0].Name)
+	assert.Empty(t, token)
+}
+
+func TestSubscriptionBacklog(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.Subscription(context.Background(), "SubName", "t13")
+	assert.Equal(t, notFound("topic"), err)
+	_, err = tips.CreateTopic(context.Background(), "t13")
+	assert.NoError(t, err)
+	_, err = tips.Subscription(context.Background(), "SubName", "t13")
+	assert.Equal(t, notFound("subname"), err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t13", nil)
+	assert.NoError(t, err)
+
+	info, err := tips.Subscription(context.Background(), "SubName", "t13")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Unacked)
+	assert.Nil(t, info.OldestUnacked)
+	assert.Nil(t, info.Head)
+
+	ids, err := tips.Publish(context.Background(), []string{"1", "2", "3"}, "t13")
+	assert.NoError(t, err)
+	ms, err := tips.Pull(context.Background(), &PullReq{SubName: "SubName", Topic: "t13", Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	info, err = tips.Subscription(context.Background(), "SubName", "t13")
+	assert.NoError(t, err)
+	assert.Equal(t, "SubName", info.Name)
+	assert.Equal(t, int64(3), info.Unacked)
+	assert.Equal(t, int64(1), info.Outstanding)
+	assert.Equal(t, int64(2), info.Undelivered)
+	assert.Equal(t, ids[0], info.OldestUnacked.String())
+	assert.Equal(t, ids[2], info.Head.String())
+	assert.False(t, info.Truncated)
+
+	assert.NoError(t, tips.Ack(context.Background(), ms[0].ID, "t13", "SubName"))
+	info, err = tips.Subscription(context.Background(), "SubName", "t13")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), info.Unacked)
+	assert.Equal(t, ids[1], info.OldestUnacked.String())
+}
+
+func TestStats(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t14")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t14", nil)
+	assert.NoError(t, err)
+	_, err = tips.Publish(context.Background(), []string{"1", "2"}, "t14")
+	assert.NoError(t, err)
+
+	stats, err := tips.Stats(context.Background(), MaxListLimit, MaxListLimit, 1)
+	assert.NoError(t, err)
+	var found bool
+	for _, ts := range stats {
+		if ts.Topic != "t14" {
+			continue
+		}
+		found = true
+		// Counted at most 1 message
+		assert.Equal(t, int64(1), ts.Messages)
+		assert.Len(t, ts.Subscriptions, 1)
+		assert.Equal(t, "SubName", ts.Subscriptions[0].Name)
+		assert.Equal(t, int64(1), ts.Subscriptions[0].Backlog)
+	}
+	assert.True(t, found)
+
+	// No subscription is counted beyond the limit
+	stats, err = tips.Stats(context.Background(), MaxListLimit, 0, 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, stats)
+	for _, ts := range stats {
+		assert.Empty(t, ts.Subscriptions)
+	}
+}
+
+func TestSeekTo(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.SeekToTime(context.Background(), "SubName", "t15", time.Now())
+	assert.Equal(t, notFound("topic"), err)
+	_, err = tips.CreateTopic(context.Background(), "t15")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "SubName", "t15", nil)
+	assert.NoError(t, err)
+
+	ids, err := tips.Publish(context.Background(), []string{"1"}, "t15")
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	middle := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	_, err = tips.Publish(context.Background(), []string{"2"}, "t15")
+	assert.NoError(t, err)
+	req := &PullReq{SubName: "SubName", Topic: "t15", Limit: 10, AutoACK: true}
+	ms, err := tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+
+	// Replay the messages published after the time
+	_, err = tips.SeekToTime(context.Background(), "SubName", "t15", middle)
+	assert.NoError(t, err)
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, "2", string(ms[0].Payload))
+
+	// Replay the messages after the offset
+	_, err = tips.SeekToOffset(context.Background(), "SubName", "t15", mustOffset(ids[0]))
+	assert.NoError(t, err)
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, "2", string(ms[0].Payload))
+
+	_, err = tips.SeekToTime(context.Background(), "SubName", "t15", middle.Add(-time.Hour))
+	assert.NoError(t, err)
+	ms, err = tips.Pull(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+}
+
+func TestStartPosition(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	_, err = tips.CreateTopic(context.Background(), "t17")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(context.Background(), "s0", "t17", &StartPosition{Earliest: true})
+	assert.NoError(t, err)
+	ids, err := tips.Publish(context.Background(), []string{"1"}, "t17")
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	middle := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	_, err = tips.Publish(context.Background(), []string{"2"}, "t17")
+	assert.NoError(t, err)
+	_, err = tips.CreateSnapshots(context.Background(), "snap", "s0", "t17")
+	assert.NoError(t, err)
+
+	_, err = tips.Subscribe(context.Background(), "s1", "t17", &StartPosition{Earliest: true, Time: middle})
+	assert.Error(t, err)
+	_, err = tips.Subscribe(context.Background(), "s1", "t17", &StartPosition{Snapshot: "snap"})
+	assert.Error(t, err)
+	_, err = tips.Subscribe(context.Background(), "s1", "t17", &StartPosition{Snapshot: "none", SnapshotSubscription: "s0"})
+	assert.Equal(t, notFound("snapshot"), err)
+
+	pull := func(subName string) []string {
+		ms, err := tips.Pull(context.Background(), &PullReq{SubName: subName, Topic: "t17", Limit: 10, AutoACK: true})
+		assert.NoError(t, err)
+		var payloads []string
+		for _, m := range ms {
+			payloads = append(payloads, string(m.Payload))
+		}
+		return payloads
+	}
+	for name, start := range map[string]*StartPosition{
+		"latest":   nil,
+		"earliest": {Earliest: true},
+		"time":     {Time: middle},
+		"offset":   {Offset: mustOffset(ids[0])},
+		"snapshot": {Snapshot: "snap", SnapshotSubscription: "s0"},
+	} {
+		_, err = tips.Subscribe(context.Background(), name, "t17", start)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, pull("latest"), 0)
+	assert.Equal(t, []string{"1", "2"}, pull("earliest"))
+	assert.Equal(t, []string{"2"}, pull("time"))
+	assert.Equal(t, []string{"2"}, pull("offset"))
+	assert.Equal(t, []string{"1", "2"}, pull("snapshot"))
+
+	// The start position of an existing subscription is ignored
+	_, err = tips.Subscribe(context.Background(), "earliest", "t17", &StartPosition{Earliest: true})
+	assert.NoError(t, err)
+	assert.Len(t, pull("earliest"), 0)
+}
+
+func TestStartEarliest(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	// The first message of the first batch is at index 0 of an unpartitioned
+	// topic, so the subscription starts right before it at index -1
+	for topic, partitions := range map[string]int{"t18": 0, "t18-p": 4} {
+		_, err = tips.CreatePartitionedTopic(ctx, topic, partitions)
+		assert.NoError(t, err)
+		_, err = tips.SetRetention(ctx, topic, &pubsub.Retention{Acked: true})
+		assert.NoError(t, err)
+		ids, err := tips.Publish(ctx, []string{"1", "2", "3"}, topic)
+		assert.NoError(t, err)
+		sub, err := tips.Subscribe(ctx, "s1", topic, &StartPosition{Earliest: true})
+		assert.NoError(t, err)
+		first := mustOffset(ids[0])
+		for _, id := range ids[1:] {
+			if o := mustOffset(id); o.Cmp(first) < 0 {
+				first = o
+			}
+		}
+		assert.Equal(t, first.Prev(), sub.Sent, topic)
+		assert.Equal(t, first.Prev(), sub.Acked, topic)
+
+		// The messages are neither skipped by the pulls nor trimmed as acked
+		assert.NoError(t, pubsub.NewTrimmer(tips.ps, time.Second, 10, 0).Trim(ctx))
+		ms, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: topic, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, ms, 3, topic)
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "ordered", "t1", nil)
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "unordered", "t1", nil)
+	assert.NoError(t, err)
+	sub, err := tips.SetOrdered(ctx, "ordered", "t1", true)
+	assert.NoError(t, err)
+	assert.True(t, sub.Ordered)
+	_, err = tips.SetOrdered(ctx, "s0", "t1", true)
+	assert.Equal(t, notFound("subname"), err)
+
+	ids, err := tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("a1"), OrderingKey: "a"},
+		{Payload: []byte("b1"), OrderingKey: "b"},
+		{Payload: []byte("a2"), OrderingKey: "a"},
+		{Payload: []byte("c")},
+		{Payload: []byte("b2"), OrderingKey: "b"},
+	}, "t1")
+	assert.NoError(t, err)
+
+	pull := func(subName string) []string {
+		msgs, err := tips.Pull(ctx, &PullReq{SubName: subName, Topic: "t1", Limit: 10})
+		assert.NoError(t, err)
+		var payloads []string
+		for _, msg := range msgs {
+			payloads = append(payloads, string(msg.Payload))
+		}
+		return payloads
+	}
+	assert.Equal(t, []string{"a1", "b1", "a2", "c", "b2"}, pull("unordered"))
+
+	// The later messages of a key are held back until the earlier ones are acked
+	assert.Equal(t, []string{"a1", "b1", "c"}, pull("ordered"))
+	assert.Nil(t, pull("ordered"))
+
+	assert.NoError(t, tips.Ack(ctx, ids[0], "t1", "ordered"))
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "ordered", Topic: "t1", Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, ids[2], msgs[0].ID)
+		assert.Equal(t, "a", msgs[0].OrderingKey)
+		assert.Equal(t, 1, msgs[0].DeliveryAttempt)
+	}
+
+	// A nacked message is redelivered before the later ones of its key
+	assert.NoError(t, tips.Nack(ctx, ids[1], "t1", "ordered"))
+	assert.Equal(t, []string{"b1"}, pull("ordered"))
+	assert.NoError(t, tips.Ack(ctx, ids[1], "t1", "ordered"))
+	assert.NoError(t, tips.Ack(ctx, ids[2], "t1", "ordered"))
+	assert.NoError(t, tips.Ack(ctx, ids[3], "t1", "ordered"))
+	assert.Equal(t, []string{"b2"}, pull("ordered"))
+	assert.NoError(t, tips.Ack(ctx, ids[4], "t1", "ordered"))
+
+	info, err := tips.Subscription(ctx, "ordered", "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Unacked)
+	assert.Equal(t, ids[4], info.Subscription.Acked.String())
+
+	// Auto acked messages do not hold back the later ones
+	_, err = tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("a3"), OrderingKey: "a"},
+		{Payload: []byte("a4"), OrderingKey: "a"},
+	}, "t1")
+	assert.NoError(t, err)
+	msgs, err = tips.Pull(ctx, &PullReq{SubName: "ordered", Topic: "t1", Limit: 10, AutoACK: true})
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 2)
+}
+
+func TestOrderedLeaseScan(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "ordered", "t1", nil)
+	assert.NoError(t, err)
+	_, err = tips.SetOrdered(ctx, "ordered", "t1", true)
+	assert.NoError(t, err)
+
+	// The first message expires before it is redelivered, so no message is redelivered
+	// and a new message would be pulled if all the leases were scanned
+	msgs := []*Message{{Payload: []byte("expiring"), TTL: time.Minute}}
+	for i := 0; i <= maxFiltered; i++ {
+		msgs = append(msgs, &Message{Payload: []byte("leased"), OrderingKey: fmt.Sprint(i)})
+	}
+	_, err = tips.PublishMessages(ctx, msgs, "t1")
+	assert.NoError(t, err)
+	pulled, err := tips.Pull(ctx, &PullReq{SubName: "ordered", Topic: "t1", Limit: int64(len(msgs)), AckDeadline: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, pulled, len(msgs))
+	_, err = tips.Publish(ctx, []string{"new"}, "t1")
+	assert.NoError(t, err)
+
+	// The leases beyond maxFiltered after the limit are not scanned, the new message
+	// is held back as its key is not known to be free, and the pull is retried at once
+	clock.now = clock.now.Add(2 * time.Minute)
+	pulled, next, err := tips.TryPull(ctx, &PullReq{SubName: "ordered", Topic: "t1", Limit: 1, AckDeadline: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, pulled, 0)
+	assert.Equal(t, clock.now, next)
+}
+
+func TestTTL(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	tips.SetClock(clock)
+	ctx := context.Background()
+	_, err = tips.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = tips.SetTTL(ctx, "t1", -time.Second)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.SetTTL(ctx, "t2", time.Minute)
+	assert.Equal(t, NotFound, ErrorCode(err))
+	topic, err := tips.SetTTL(ctx, "t1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, topic.TTL)
+
+	_, err = tips.PublishMessages(ctx, []*Message{{Payload: []byte("1"), TTL: -time.Second}}, "t1")
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("topic")},
+		{Payload: []byte("short"), TTL: time.Second},
+		{Payload: []byte("long"), TTL: time.Hour},
+		// The TTL starts when it is due
+		{Payload: []byte("delayed"), Delay: time.Hour, TTL: time.Second},
+	}, "t1")
+	assert.NoError(t, err)
+
+	// Expired in a lease, it is not redelivered
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 1, AckDeadline: time.Second})
+	assert.NoError(t, err)
+	if assert.Len(t, msgs, 1) {
+		assert.Equal(t, "topic", string(msgs[0].Payload))
+	}
+
+	// Both the leased and the undelivered expired messages are counted
+	expired := expiredCount(t, "pull")
+	clock.now = clock.now.Add(2 * time.Minute)
+	assert.Equal(t, []string{"long"}, pullPayloads(t, tips, "s1", "t1"))
+	assert.Equal(t, expired+2, expiredCount(t, "pull"))
+	info, err := tips.Subscription(ctx, "s1", "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Outstanding)
+
+	clock.now = clock.now.Add(58 * time.Minute)
+	count, err := tips.DeliverScheduled(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"delayed"}, pullPayloads(t, tips, "s1", "t1"))
+}
+
+func TestPartitioned(t *testing.T) {
+	tips, err := MockTips()
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+	_, err = tips.CreatePartitionedTopic(ctx, "t1", -1)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.CreatePartitionedTopic(ctx, "t1", pubsub.MaxPartitions+1)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	topic, err := tips.CreatePartitionedTopic(ctx, "t1", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, topic.Partitions)
+	// Created again with the same partitions
+	again, err := tips.CreatePartitionedTopic(ctx, "t1", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, topic.ObjectID, again.ObjectID)
+	_, err = tips.CreatePartitionedTopic(ctx, "t1", 2)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	// A single partition is the same as none
+	_, err = tips.CreateTopic(ctx, "t2")
+	assert.NoError(t, err)
+	single, err := tips.CreatePartitionedTopic(ctx, "t2", 1)
+	assert.NoError(t, err)
+	assert.False(t, single.Partitioned())
+	single, err = tips.CreatePartitionedTopic(ctx, "t3", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, single.Partitions)
+	_, err = tips.CreatePartitionedTopic(ctx, "t3", 0)
+	assert.NoError(t, err)
+	_, err = tips.CreatePartitionedTopic(ctx, "t3", 4)
+	assert.Equal(t, InvalidArgument, ErrorCode(err))
+	_, err = tips.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	ids, err := tips.PublishMessages(ctx, []*Message{
+		{Payload: []byte("1")},
+		{Payload: []byte("2"), OrderingKey: "a"},
+		{Payload: []byte("3")},
+		{Payload: []byte("4"), Delay: time.Hour},
+		{Payload: []byte("5"), OrderingKey: "a"},
+	}, "t1")
+	assert.NoError(t, err)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			assert.NotEqual(t, ids[i], ids[j])
+		}
+	}
+	_, err = tips.Publish(ctx, []string{"6", "7"}, "t1")
+	assert.NoError(t, err)
+
+	// The partitions are merged in the order of publishing
+	msgs, err := tips.Pull(ctx, &PullReq{SubName: "s1", Topic: "t1", Limit: 10})
+	assert.NoError(t, err)
+	var payloads []string
+	for _, msg := range msgs {
+		payloads = append(payloads, string(msg.Payload))
+		assert.NoError(t, tips.Ack(ctx, msg.ID, "t1", "s1"))
+	}
+	assert.Equal(t, []string{"1", "2", "3", "5", "6", "7"}, payloads)
+	info, err := tips.Subscription(ctx, "s1", "t1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Unacked)
+}
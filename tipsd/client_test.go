@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tipsio/tips/client"
+)
+
+func TestClient(t *testing.T) {
+	c := client.NewClient(url)
+	ctx := context.Background()
+
+	_, err := c.Topic(ctx, "t10")
+	assert.True(t, client.IsNotFound(err))
+	topic, err := c.CreateTopic(ctx, "t10", &client.Retention{MaxAge: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, "t10", topic.Name)
+	assert.Equal(t, time.Hour, topic.Retention.MaxAge)
+	topic, err = c.SetTTL(ctx, "t10", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, topic.TTL)
+	assert.Equal(t, time.Hour, topic.Retention.MaxAge)
+
+	_, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{Filter: "attributes:"})
+	e, ok := err.(*client.Error)
+	require.True(t, ok)
+	assert.Equal(t, 400, e.StatusCode)
+	sub, err := c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{Filter: "attributes:lang"})
+	require.NoError(t, err)
+	assert.Equal(t, "attributes:lang", sub.Filter)
+
+	ids, err := c.Publish(ctx, "t10", []*client.Message{
+		{Payload: []byte("hello")},
+		{Payload: []byte("tips"), Attributes: map[string]string{"lang": "go"}, DedupKey: "k1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	dup, err := c.Publish(ctx, "t10", []*client.Message{
+		{Payload: []byte("tips"), Attributes: map[string]string{"lang": "go"}, DedupKey: "k1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ids[1:], dup)
+	// Invisible until it is due
+	_, err = c.Publish(ctx, "t10", []*client.Message{
+		{Payload: []byte("later"), Attributes: map[string]string{"lang": "go"}, Delay: time.Hour},
+	})
+	require.NoError(t, err)
+
+	msgs, err := c.Pull(ctx, "t10", "s1", &client.PullOptions{Limit: 10, AckDeadline: time.Second})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, ids[1], msgs[0].ID)
+	assert.Equal(t, "tips", string(msgs[0].Payload))
+	assert.Equal(t, map[string]string{"lang": "go"}, msgs[0].Attributes)
+	assert.Equal(t, 1, msgs[0].DeliveryAttempt)
+
+	require.NoError(t, c.ModifyAckDeadline(ctx, "t10", "s1", msgs[0].ID, time.Minute))
+	require.NoError(t, c.Nack(ctx, "t10", "s1", msgs[0].ID))
+	require.NoError(t, c.CreateSnapshot(ctx, "t10", "s1", "snap"))
+	// Subscribing an existing subscription returns it
+	snapped, err := c.Subscribe(ctx, "t10", "s1", nil)
+	require.NoError(t, err)
+	msgs, err = c.Pull(ctx, "t10", "s1", nil)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.NoError(t, c.Ack(ctx, "t10", "s1", msgs[0].ID))
+	sub, err = c.Seek(ctx, "t10", "s1", "snap")
+	require.NoError(t, err)
+	assert.Equal(t, snapped.Acked, sub.Acked)
+	info, err := c.Subscription(ctx, "t10", "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "s1", info.Name)
+	assert.Equal(t, int64(1), info.Unacked)
+	// The message is redelivered after the seek
+	assert.Equal(t, int64(1), info.Undelivered)
+	assert.Equal(t, msgs[0].ID, fmt.Sprintf("%d-%d", info.OldestUnacked.TS, info.OldestUnacked.Index))
+	subs, token, err := c.ListSubscriptions(ctx, "t10", &client.ListOptions{Prefix: "s"})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, "s1", subs[0].Name)
+	assert.Empty(t, token)
+	snapshots, _, err := c.ListSnapshots(ctx, "t10", "s1", nil)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "snap", snapshots[0].Name)
+	assert.Equal(t, "s1", snapshots[0].Subscription.Name)
+	sub, err = c.SeekToOffset(ctx, "t10", "s1", ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, ids[1], fmt.Sprintf("%d-%d", sub.Acked.TS, sub.Acked.Index))
+	msgs, err = c.Pull(ctx, "t10", "s1", nil)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 0)
+	_, err = c.SeekToTime(ctx, "t10", "s1", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	msgs, err = c.Pull(ctx, "t10", "s1", nil)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, ids[1], msgs[0].ID)
+	require.NoError(t, c.DeleteSnapshot(ctx, "t10", "s1", "snap"))
+
+	_, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{Filter: "attributes:lang", ClearFilter: true})
+	assert.Error(t, err)
+	sub, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{ClearFilter: true})
+	require.NoError(t, err)
+	assert.Empty(t, sub.Filter)
+	sub, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{PushEndpoint: "http://localhost/push"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/push", sub.PushEndpoint)
+	_, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{PushEndpoint: "http://localhost/push", ClearPushEndpoint: true})
+	assert.Error(t, err)
+	sub, err = c.Subscribe(ctx, "t10", "s1", &client.SubscribeOptions{ClearPushEndpoint: true})
+	require.NoError(t, err)
+	assert.Empty(t, sub.PushEndpoint)
+
+	require.NoError(t, c.Unsubscribe(ctx, "t10", "s1"))
+	_, _, err = c.ListSubscriptions(ctx, "t10", &client.ListOptions{Token: "!"})
+	e, ok = err.(*client.Error)
+	require.True(t, ok)
+	assert.Equal(t, 400, e.StatusCode)
+	assert.True(t, client.IsNotFound(c.Ack(ctx, "t10", "s1", msgs[0].ID)))
+	require.NoError(t, c.DeleteTopic(ctx, "t10"))
+}
+
+func TestClientReceive(t *testing.T) {
+	c := client.NewClient(url)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// The messages are received from all the partitions
+	topic, err := c.CreatePartitionedTopic(ctx, "t11", 4)
+	require.NoError(t, err)
+	assert.Equal(t, 4, topic.Partitions)
+	_, err = c.Subscribe(ctx, "t11", "s1", nil)
+	require.NoError(t, err)
+
+	p := c.Publisher("t11", nil)
+	for _, payload := range []string{"a", "b", "c"} {
+		p.Publish(&client.Message{Payload: []byte(payload)})
+	}
+	p.Stop()
+
+	var mu sync.Mutex
+	received := make(map[string]bool)
+	err = c.Subscriber("t11", "s1", &client.ReceiveSettings{PullTimeout: time.Second}).Receive(ctx,
+		func(_ context.Context, msg *client.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[string(msg.Payload)] = true
+			if len(received) == 3 {
+				cancel()
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "c": true}, received)
+}
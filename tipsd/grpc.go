@@ -0,0 +1,555 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/tipsio/tips"
+	"github.com/tipsio/tips/conf"
+	"github.com/tipsio/tips/metrics"
+	"github.com/tipsio/tips/store/pubsub"
+	"github.com/tipsio/tips/tipspb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer serves the gRPC API which mirrors the HTTP API of Server
+type GRPCServer struct {
+	pubsub *tips.Tips
+	server *grpc.Server
+}
+
+// NewGRPCServer creates a gRPC server, TLS is enabled if the cert and key files are given
+func NewGRPCServer(conf *conf.Server, pubsub *tips.Tips) (*GRPCServer, error) {
+	var opts []grpc.ServerOption
+	if conf.Cert != "" && conf.Key != "" {
+		creds, err := credentials.NewServerTLSFromFile(conf.Cert, conf.Key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s := &GRPCServer{pubsub: pubsub, server: grpc.NewServer(opts...)}
+	tipspb.RegisterTipsServer(s.server, s)
+	return s, nil
+}
+
+// Serve accepts incoming connections on the Listener lis
+func (s *GRPCServer) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Stop closes all the connections and cancels the pending RPCs
+func (s *GRPCServer) Stop() error {
+	s.server.Stop()
+	return nil
+}
+
+// GracefulStop waits for the pending RPCs for at most a second, the streams
+// and the waiting pulls are cancelled after that
+func (s *GRPCServer) GracefulStop() error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.server.Stop()
+	}
+	return nil
+}
+
+// grpcCodes maps the codes of the errors returned by tips to gRPC codes
+var grpcCodes = map[tips.Code]codes.Code{
+	tips.Internal:        codes.Internal,
+	tips.NotFound:        codes.NotFound,
+	tips.AlreadyExists:   codes.AlreadyExists,
+	tips.Conflict:        codes.Aborted,
+	tips.InvalidArgument: codes.InvalidArgument,
+	tips.Unavailable:     codes.Unavailable,
+}
+
+// grpcError converts an error of tips to a gRPC status error
+func grpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(grpcCodes[tips.ErrorCode(err)], err.Error())
+}
+
+func toTopic(t *tips.Topic) *tipspb.Topic {
+	topic := &tipspb.Topic{
+		Name:       t.Name,
+		ObjectId:   t.ObjectID,
+		CreatedAt:  t.CreatedAt,
+		Ttl:        int64(t.TTL / time.Second),
+		Partitions: int32(t.Partitions),
+	}
+	if r := t.Retention; r != nil {
+		topic.Retention = &tipspb.Retention{
+			MaxAge:   int64(r.MaxAge / time.Second),
+			MaxCount: r.MaxCount,
+			Acked:    r.Acked,
+		}
+	}
+	return topic
+}
+
+func toSubscription(sub *tips.Subscription) *tipspb.Subscription {
+	s := &tipspb.Subscription{
+		Name:                sub.Name,
+		DeadLetterTopic:     sub.DeadLetterTopic,
+		MaxDeliveryAttempts: int32(sub.MaxDeliveryAttempts),
+		Filter:              sub.Filter,
+		PushEndpoint:        sub.PushEndpoint,
+		Ordered:             sub.Ordered,
+	}
+	if sub.Sent != nil {
+		s.Sent = sub.Sent.String()
+	}
+	if sub.Acked != nil {
+		s.Acked = sub.Acked.String()
+	}
+	return s
+}
+
+func toSnapshot(snap *tips.Snapshot) *tipspb.Snapshot {
+	snapshot := &tipspb.Snapshot{Name: snap.Name}
+	if snap.Subscription != nil {
+		snapshot.Subscription = toSubscription(&tips.Subscription{Subscription: *snap.Subscription})
+	}
+	return snapshot
+}
+
+func toMessages(msgs []*tips.Message) []*tipspb.Message {
+	ms := make([]*tipspb.Message, len(msgs))
+	for i, msg := range msgs {
+		ms[i] = &tipspb.Message{
+			Id:              msg.ID,
+			Payload:         msg.Payload,
+			Attributes:      msg.Attributes,
+			PublishTime:     msg.PublishTime,
+			DeliveryAttempt: int32(msg.DeliveryAttempt),
+			OrderingKey:     msg.OrderingKey,
+			ScheduleId:      msg.ScheduleID,
+		}
+	}
+	return ms
+}
+
+// CreateTopic creates a topic, the retention policy and the TTL are updated if they are given,
+// a new topic is partitioned if the partitions are given
+func (s *GRPCServer) CreateTopic(ctx context.Context, req *tipspb.CreateTopicRequest) (*tipspb.Topic, error) {
+	start := time.Now()
+	var retention *pubsub.Retention
+	if r := req.Retention; r != nil {
+		var err error
+		if retention, err = newRetention(r.MaxAge, r.MaxCount, r.Acked); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if req.Ttl != nil && req.Ttl.Seconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl should not be negative")
+	}
+	var t *tips.Topic
+	var err error
+	if req.Partitions != 0 {
+		t, err = s.pubsub.CreatePartitionedTopic(ctx, req.Topic, int(req.Partitions))
+	} else {
+		t, err = s.pubsub.CreateTopic(ctx, req.Topic)
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if retention != nil {
+		if t, err = s.pubsub.SetRetention(ctx, req.Topic, retention); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+	if req.Ttl != nil {
+		if t, err = s.pubsub.SetTTL(ctx, req.Topic, time.Duration(req.Ttl.Seconds)*time.Second); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("create").Observe(time.Since(start).Seconds())
+	return toTopic(t), nil
+}
+
+// GetTopic returns a topic queried by name
+func (s *GRPCServer) GetTopic(ctx context.Context, req *tipspb.GetTopicRequest) (*tipspb.Topic, error) {
+	start := time.Now()
+	t, err := s.pubsub.Topic(ctx, req.Topic)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("topic").Observe(time.Since(start).Seconds())
+	return toTopic(t), nil
+}
+
+// DeleteTopic deletes a topic
+func (s *GRPCServer) DeleteTopic(ctx context.Context, req *tipspb.DeleteTopicRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if err := s.pubsub.Destroy(ctx, req.Topic); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("delete").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// ListTopics lists the topics in pages, the next page is listed with the next
+// token of the previous one
+func (s *GRPCServer) ListTopics(ctx context.Context, req *tipspb.ListTopicsRequest) (*tipspb.ListTopicsResponse, error) {
+	start := time.Now()
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit should not be negative")
+	}
+	topics, next, err := s.pubsub.ListTopics(ctx, req.Prefix, req.Token, int(req.Limit))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+	resp := &tipspb.ListTopicsResponse{NextToken: next}
+	for _, t := range topics {
+		resp.Topics = append(resp.Topics, toTopic(t))
+	}
+	return resp, nil
+}
+
+// Publish publishes messages and returns their ids in the same order
+func (s *GRPCServer) Publish(ctx context.Context, req *tipspb.PublishRequest) (*tipspb.PublishResponse, error) {
+	start := time.Now()
+	if len(req.Messages) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "msg is not null")
+	}
+	msgs := make([]*tips.Message, len(req.Messages))
+	var size float64
+	for i, msg := range req.Messages {
+		msgs[i] = &tips.Message{
+			Payload:     msg.Payload,
+			Attributes:  msg.Attributes,
+			DedupKey:    msg.DedupKey,
+			OrderingKey: msg.OrderingKey,
+			DeliverAt:   msg.DeliverAt,
+			Delay:       time.Duration(msg.Delay) * time.Second,
+			TTL:         time.Duration(msg.Ttl) * time.Second,
+		}
+		size += float64(len(msg.Payload))
+	}
+	msgids, err := s.pubsub.PublishMessages(ctx, msgs, req.Topic)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("publish").Observe(time.Since(start).Seconds())
+	metrics.GetMetrics().MessagesSizeHistogramVec.WithLabelValues("publish").Observe(size)
+	return &tipspb.PublishResponse{MessageIds: msgids}, nil
+}
+
+// Ack acknowledges a message
+func (s *GRPCServer) Ack(ctx context.Context, req *tipspb.AckRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if err := s.pubsub.Ack(ctx, req.MessageId, req.Topic, req.Subscription); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("ack").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// Nack makes a message redelivered on the next pull
+func (s *GRPCServer) Nack(ctx context.Context, req *tipspb.NackRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if err := s.pubsub.Nack(ctx, req.MessageId, req.Topic, req.Subscription); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("nack").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// ModifyAckDeadline resets the ack deadline of a pulled message
+func (s *GRPCServer) ModifyAckDeadline(ctx context.Context, req *tipspb.ModifyAckDeadlineRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if req.Deadline < 0 {
+		return nil, status.Error(codes.InvalidArgument, "deadline should not be negative")
+	}
+	deadline := time.Duration(req.Deadline) * time.Second
+	if err := s.pubsub.ModifyAckDeadline(ctx, req.MessageId, req.Topic, req.Subscription, deadline); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("deadline").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// Subscribe subscribes a topic, the dead letter policy, the filter and the push
+// endpoint of the subscription are updated if they are given
+func (s *GRPCServer) Subscribe(ctx context.Context, req *tipspb.SubscribeRequest) (*tipspb.Subscription, error) {
+	start := time.Now()
+	sreq := &subscribeReq{}
+	if req.ClearPushEndpoint {
+		if req.PushEndpoint != "" {
+			return nil, status.Error(codes.InvalidArgument, "push endpoint should be empty to clear the push endpoint")
+		}
+		sreq.PushEndpoint = &req.PushEndpoint
+	} else if req.PushEndpoint != "" {
+		sreq.PushEndpoint = &req.PushEndpoint
+	}
+	if req.ClearFilter {
+		if req.Filter != "" {
+			return nil, status.Error(codes.InvalidArgument, "filter should be empty to clear the filter")
+		}
+		sreq.Filter = &req.Filter
+	} else if req.Filter != "" {
+		sreq.Filter = &req.Filter
+	}
+	if p := req.DeadLetterPolicy; p != nil {
+		sreq.DeadLetterTopic = &p.Topic
+		sreq.MaxDeliveryAttempts = int(p.MaxDeliveryAttempts)
+	}
+	if o := req.Ordering; o != nil {
+		sreq.Ordered = &o.Enabled
+	}
+	if p := req.Start; p != nil {
+		sreq.Start = &startPosition{
+			Earliest:             p.Earliest,
+			Offset:               p.Offset,
+			Snapshot:             p.Snapshot,
+			SnapshotSubscription: p.SnapshotSubscription,
+		}
+		if p.Time != 0 {
+			t := time.Unix(0, p.Time)
+			sreq.Start.Time = &t
+		}
+	}
+	if err := sreq.validate(req.Topic); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sub, err := subscribe(ctx, s.pubsub, req.Subscription, req.Topic, sreq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("sub").Observe(time.Since(start).Seconds())
+	return toSubscription(sub), nil
+}
+
+// Unsubscribe deletes a subscription
+func (s *GRPCServer) Unsubscribe(ctx context.Context, req *tipspb.UnsubscribeRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if err := s.pubsub.Unsubscribe(ctx, req.Subscription, req.Topic); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("unsub").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// GetSubscription returns a subscription with its backlog
+func (s *GRPCServer) GetSubscription(ctx context.Context, req *tipspb.GetSubscriptionRequest) (*tipspb.SubscriptionInfo, error) {
+	start := time.Now()
+	info, err := s.pubsub.Subscription(ctx, req.Subscription, req.Topic)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("subscription").Observe(time.Since(start).Seconds())
+	resp := &tipspb.SubscriptionInfo{
+		Subscription:     toSubscription(&info.Subscription),
+		Unacked:          info.Unacked,
+		Outstanding:      info.Outstanding,
+		Undelivered:      info.Undelivered,
+		OldestUnackedAge: int64(info.OldestUnackedAge / time.Millisecond),
+		Truncated:        info.Truncated,
+	}
+	if info.Head != nil {
+		resp.Head = info.Head.String()
+	}
+	if info.OldestUnacked != nil {
+		resp.OldestUnacked = info.OldestUnacked.String()
+	}
+	return resp, nil
+}
+
+// ListSubscriptions lists the subscriptions of a topic in pages
+func (s *GRPCServer) ListSubscriptions(ctx context.Context, req *tipspb.ListSubscriptionsRequest) (*tipspb.ListSubscriptionsResponse, error) {
+	start := time.Now()
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit should not be negative")
+	}
+	subs, next, err := s.pubsub.ListSubscriptions(ctx, req.Topic, req.Prefix, req.Token, int(req.Limit))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+	resp := &tipspb.ListSubscriptionsResponse{NextToken: next}
+	for _, sub := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, toSubscription(sub))
+	}
+	return resp, nil
+}
+
+// Pull waits until there are messages of a subscription or the timeout expires
+func (s *GRPCServer) Pull(ctx context.Context, req *tipspb.PullRequest) (*tipspb.PullResponse, error) {
+	start := time.Now()
+	if req.AckDeadline < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ack deadline should not be negative")
+	}
+	limit, timeout := req.Limit, req.Timeout
+	if limit <= 0 {
+		limit = 256
+	}
+	if timeout <= 0 {
+		timeout = 3600
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+	msgs, err := waitPull(ctx, s.pubsub, &tips.PullReq{
+		SubName:     req.Subscription,
+		Topic:       req.Topic,
+		Limit:       limit,
+		AutoACK:     req.AutoAck,
+		Offset:      req.Offset,
+		AckDeadline: time.Duration(req.AckDeadline) * time.Second,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("pull").Observe(time.Since(start).Seconds())
+	var size float64
+	for _, msg := range msgs {
+		size += float64(len(msg.Payload))
+		size += float64(len(msg.ID))
+	}
+	metrics.GetMetrics().MessagesSizeHistogramVec.WithLabelValues("pull").Observe(size)
+	return &tipspb.PullResponse{Messages: toMessages(msgs)}, nil
+}
+
+// StreamingPull streams the messages of a subscription with the flow control given
+// by the first request, the stream ends once the client closes its sending side
+func (s *GRPCServer) StreamingPull(ss tipspb.Tips_StreamingPullServer) error {
+	req, err := ss.Recv()
+	if err != nil {
+		return err
+	}
+	if req.MaxOutstanding < 0 || req.MaxOutstandingBytes < 0 || req.AckDeadline < 0 {
+		return status.Error(codes.InvalidArgument, "flow control should not be negative")
+	}
+	st := newStream(s.pubsub, req.Topic, req.Subscription)
+	if req.MaxOutstanding > 0 {
+		st.maxOutstanding = int(req.MaxOutstanding)
+	}
+	if req.MaxOutstandingBytes > 0 {
+		st.maxOutstandingBytes = int(req.MaxOutstandingBytes)
+	}
+	st.ackDeadline = time.Duration(req.AckDeadline) * time.Second
+
+	ctx := ss.Context()
+	w, err := s.pubsub.Watch(ctx, st.topic)
+	if err != nil {
+		return grpcError(err)
+	}
+	defer w.Close()
+	published := w.C()
+	msgs, next, err := st.pull(ctx)
+	if err != nil {
+		return grpcError(err)
+	}
+	// The first request may ack or nack the messages of a previous stream
+	st.conn = &grpcStream{ss: ss, first: &StreamRequest{Ack: req.AckIds, Nack: req.NackIds}}
+	err = st.serve(ctx, w, published, msgs, next)
+	if err == io.EOF || ctx.Err() != nil {
+		return nil
+	}
+	zap.L().Debug("stream ended", zap.Error(err))
+	return grpcError(err)
+}
+
+// grpcStream serves a stream over a gRPC bidirectional stream
+type grpcStream struct {
+	ss    tipspb.Tips_StreamingPullServer
+	first *StreamRequest
+}
+
+func (gs *grpcStream) Send(msgs []*tips.Message) error {
+	return gs.ss.Send(&tipspb.StreamingPullResponse{Messages: toMessages(msgs)})
+}
+
+func (gs *grpcStream) Recv() (*StreamRequest, error) {
+	if req := gs.first; req != nil {
+		gs.first = nil
+		return req, nil
+	}
+	req, err := gs.ss.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return &StreamRequest{Ack: req.AckIds, Nack: req.NackIds}, nil
+}
+
+// Fail does nothing because the error is returned to the client by StreamingPull
+func (gs *grpcStream) Fail(err error) {}
+
+// CreateSnapshot creates a snapshot of a subscription
+func (s *GRPCServer) CreateSnapshot(ctx context.Context, req *tipspb.CreateSnapshotRequest) (*tipspb.Snapshot, error) {
+	start := time.Now()
+	snap, err := s.pubsub.CreateSnapshots(ctx, req.Name, req.Subscription, req.Topic)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("create").Observe(time.Since(start).Seconds())
+	return toSnapshot(snap), nil
+}
+
+// ListSnapshots lists the snapshots of a subscription in pages
+func (s *GRPCServer) ListSnapshots(ctx context.Context, req *tipspb.ListSnapshotsRequest) (*tipspb.ListSnapshotsResponse, error) {
+	start := time.Now()
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit should not be negative")
+	}
+	snapshots, next, err := s.pubsub.ListSnapshots(ctx, req.Subscription, req.Topic, req.Prefix, req.Token, int(req.Limit))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+	resp := &tipspb.ListSnapshotsResponse{NextToken: next}
+	for _, snap := range snapshots {
+		resp.Snapshots = append(resp.Snapshots, toSnapshot(snap))
+	}
+	return resp, nil
+}
+
+// DeleteSnapshot deletes a snapshot
+func (s *GRPCServer) DeleteSnapshot(ctx context.Context, req *tipspb.DeleteSnapshotRequest) (*tipspb.Empty, error) {
+	start := time.Now()
+	if err := s.pubsub.DeleteSnapshots(ctx, req.Name, req.Subscription, req.Topic); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("delete").Observe(time.Since(start).Seconds())
+	return &tipspb.Empty{}, nil
+}
+
+// Seek resets a subscription to a snapshot, a time or an offset
+func (s *GRPCServer) Seek(ctx context.Context, req *tipspb.SeekRequest) (*tipspb.Subscription, error) {
+	start := time.Now()
+	var sub *tips.Subscription
+	var err error
+	switch {
+	case req.Snapshot != "" && req.Time == 0 && req.Offset == "":
+		sub, err = s.pubsub.Seek(ctx, req.Snapshot, req.Subscription, req.Topic)
+	case req.Snapshot == "" && req.Time != 0 && req.Offset == "":
+		sub, err = s.pubsub.SeekToTime(ctx, req.Subscription, req.Topic, time.Unix(0, req.Time))
+	case req.Snapshot == "" && req.Time == 0 && req.Offset != "":
+		var offset *pubsub.Offset
+		if offset, err = pubsub.OffsetFromString(req.Offset); err == nil {
+			sub, err = s.pubsub.SeekToOffset(ctx, req.Subscription, req.Topic, offset)
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "exactly one of snapshot, time and offset should be given")
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("seek").Observe(time.Since(start).Seconds())
+	return toSubscription(sub), nil
+}
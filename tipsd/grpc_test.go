@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tipsio/tips/tipspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func dialGRPC(t testing.TB) (tipspb.TipsClient, func()) {
+	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure())
+	require.NoError(t, err)
+	return tipspb.NewTipsClient(conn), func() { conn.Close() }
+}
+
+func assertGRPCCode(t testing.TB, code codes.Code, err error) {
+	s, ok := status.FromError(err)
+	require.True(t, ok, "Unexpected error: %v", err)
+	assert.Equal(t, code, s.Code(), "Unexpected status code: %v", err)
+}
+
+func TestGRPC(t *testing.T) {
+	client, closer := dialGRPC(t)
+	defer closer()
+	ctx := context.Background()
+
+	_, err := client.GetTopic(ctx, &tipspb.GetTopicRequest{Topic: "t8"})
+	assertGRPCCode(t, codes.NotFound, err)
+	_, err = client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t8", Retention: &tipspb.Retention{MaxAge: -1}})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	_, err = client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t8", Ttl: &tipspb.TTL{Seconds: -1}})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	topic, err := client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t8", Retention: &tipspb.Retention{MaxCount: 100}, Ttl: &tipspb.TTL{Seconds: 3600}})
+	require.NoError(t, err)
+	assert.Equal(t, "t8", topic.Name)
+	assert.Equal(t, int64(100), topic.Retention.MaxCount)
+	assert.Equal(t, int64(3600), topic.Ttl)
+	topic, err = client.GetTopic(ctx, &tipspb.GetTopicRequest{Topic: "t8"})
+	require.NoError(t, err)
+	assert.Equal(t, "t8", topic.Name)
+
+	_, err = client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t8-dead"})
+	require.NoError(t, err)
+	_, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", Filter: "attributes:"})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	sub, err := client.Subscribe(ctx, &tipspb.SubscribeRequest{
+		Topic:            "t8",
+		Subscription:     "s1",
+		DeadLetterPolicy: &tipspb.DeadLetterPolicy{Topic: "t8-dead", MaxDeliveryAttempts: 5},
+		Filter:           `attributes.lang = "go"`,
+		Ordering:         &tipspb.Ordering{Enabled: true},
+	})
+	require.NoError(t, err)
+	assert.True(t, sub.Ordered)
+	assert.Equal(t, "t8-dead", sub.DeadLetterTopic)
+	assert.Equal(t, int32(5), sub.MaxDeliveryAttempts)
+	assert.Equal(t, `attributes.lang = "go"`, sub.Filter)
+
+	_, err = client.Publish(ctx, &tipspb.PublishRequest{Topic: "t8"})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	pub, err := client.Publish(ctx, &tipspb.PublishRequest{Topic: "t8", Messages: []*tipspb.Message{
+		{Payload: []byte("java"), Attributes: map[string]string{"lang": "java"}},
+		{Payload: []byte("go"), Attributes: map[string]string{"lang": "go"}, DedupKey: "k1"},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, pub.MessageIds, 2)
+	dup, err := client.Publish(ctx, &tipspb.PublishRequest{Topic: "t8", Messages: []*tipspb.Message{
+		{Payload: []byte("go"), Attributes: map[string]string{"lang": "go"}, DedupKey: "k1"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, pub.MessageIds[1:], dup.MessageIds)
+	_, err = client.Publish(ctx, &tipspb.PublishRequest{Topic: "t8", Messages: []*tipspb.Message{
+		{Payload: []byte("later"), Attributes: map[string]string{"lang": "go"}, Delay: 3600},
+	}})
+	require.NoError(t, err)
+	_, err = client.Publish(ctx, &tipspb.PublishRequest{Topic: "t8", Messages: []*tipspb.Message{
+		{Payload: []byte("later"), Delay: -1},
+	}})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+
+	resp, err := client.Pull(ctx, &tipspb.PullRequest{Topic: "t8", Subscription: "s1", Timeout: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 1)
+	msg := resp.Messages[0]
+	assert.Equal(t, pub.MessageIds[1], msg.Id)
+	assert.Equal(t, "go", string(msg.Payload))
+	assert.Equal(t, map[string]string{"lang": "go"}, msg.Attributes)
+	assert.Equal(t, int32(1), msg.DeliveryAttempt)
+
+	_, err = client.ModifyAckDeadline(ctx, &tipspb.ModifyAckDeadlineRequest{Topic: "t8", Subscription: "s1", MessageId: msg.Id, Deadline: -1})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	_, err = client.Ack(ctx, &tipspb.AckRequest{Topic: "t8", Subscription: "s1", MessageId: msg.Id})
+	require.NoError(t, err)
+	// Times out without messages
+	resp, err = client.Pull(ctx, &tipspb.PullRequest{Topic: "t8", Subscription: "s1", Timeout: 1})
+	require.NoError(t, err)
+	assert.Len(t, resp.Messages, 0)
+
+	snap, err := client.CreateSnapshot(ctx, &tipspb.CreateSnapshotRequest{Topic: "t8", Subscription: "s1", Name: "snap"})
+	require.NoError(t, err)
+	assert.Equal(t, "snap", snap.Name)
+	assert.Equal(t, "s1", snap.Subscription.Name)
+	sub, err = client.Seek(ctx, &tipspb.SeekRequest{Topic: "t8", Subscription: "s1", Snapshot: "snap"})
+	require.NoError(t, err)
+	assert.Equal(t, snap.Subscription.Acked, sub.Acked)
+	topics, err := client.ListTopics(ctx, &tipspb.ListTopicsRequest{Prefix: "t8", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, topics.Topics, 1)
+	assert.Equal(t, "t8", topics.Topics[0].Name)
+	topics, err = client.ListTopics(ctx, &tipspb.ListTopicsRequest{Prefix: "t8", Token: topics.NextToken})
+	require.NoError(t, err)
+	require.Len(t, topics.Topics, 1)
+	assert.Equal(t, "t8-dead", topics.Topics[0].Name)
+	assert.Empty(t, topics.NextToken)
+	_, err = client.ListTopics(ctx, &tipspb.ListTopicsRequest{Token: "!"})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	info, err := client.GetSubscription(ctx, &tipspb.GetSubscriptionRequest{Topic: "t8", Subscription: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, "s1", info.Subscription.Name)
+	// The message filtered out is skipped rather than left unacked
+	assert.Equal(t, int64(0), info.Unacked)
+	_, err = client.GetSubscription(ctx, &tipspb.GetSubscriptionRequest{Topic: "t8", Subscription: "s2"})
+	assertGRPCCode(t, codes.NotFound, err)
+	subs, err := client.ListSubscriptions(ctx, &tipspb.ListSubscriptionsRequest{Topic: "t8"})
+	require.NoError(t, err)
+	require.Len(t, subs.Subscriptions, 1)
+	assert.Equal(t, "s1", subs.Subscriptions[0].Name)
+	snaps, err := client.ListSnapshots(ctx, &tipspb.ListSnapshotsRequest{Topic: "t8", Subscription: "s1"})
+	require.NoError(t, err)
+	require.Len(t, snaps.Snapshots, 1)
+	assert.Equal(t, "snap", snaps.Snapshots[0].Name)
+	_, err = client.Seek(ctx, &tipspb.SeekRequest{Topic: "t8", Subscription: "s1", Snapshot: "snap", Offset: msg.Id})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	sub, err = client.Seek(ctx, &tipspb.SeekRequest{Topic: "t8", Subscription: "s1", Offset: msg.Id})
+	require.NoError(t, err)
+	assert.Equal(t, msg.Id, sub.Acked)
+	sub, err = client.Seek(ctx, &tipspb.SeekRequest{Topic: "t8", Subscription: "s1", Time: time.Now().Add(-time.Hour).UnixNano()})
+	require.NoError(t, err)
+	assert.NotEqual(t, msg.Id, sub.Acked)
+	_, err = client.DeleteSnapshot(ctx, &tipspb.DeleteSnapshotRequest{Topic: "t8", Subscription: "s1", Name: "snap"})
+	require.NoError(t, err)
+
+	_, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s2", Start: &tipspb.StartPosition{Earliest: true, Offset: msg.Id}})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	sub, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s2", Start: &tipspb.StartPosition{Offset: msg.Id}})
+	require.NoError(t, err)
+	assert.Equal(t, msg.Id, sub.Sent)
+	_, err = client.Unsubscribe(ctx, &tipspb.UnsubscribeRequest{Topic: "t8", Subscription: "s2"})
+	require.NoError(t, err)
+
+	_, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", Filter: "attributes:lang", ClearFilter: true})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	sub, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", ClearFilter: true})
+	require.NoError(t, err)
+	assert.Empty(t, sub.Filter)
+	sub, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", PushEndpoint: "http://localhost/push"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/push", sub.PushEndpoint)
+	_, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", PushEndpoint: "http://localhost/push", ClearPushEndpoint: true})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	sub, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t8", Subscription: "s1", ClearPushEndpoint: true})
+	require.NoError(t, err)
+	assert.Empty(t, sub.PushEndpoint)
+
+	_, err = client.Unsubscribe(ctx, &tipspb.UnsubscribeRequest{Topic: "t8", Subscription: "s1"})
+	require.NoError(t, err)
+	_, err = client.Ack(ctx, &tipspb.AckRequest{Topic: "t8", Subscription: "s1", MessageId: msg.Id})
+	assertGRPCCode(t, codes.NotFound, err)
+	_, err = client.DeleteTopic(ctx, &tipspb.DeleteTopicRequest{Topic: "t8"})
+	require.NoError(t, err)
+	_, err = client.DeleteTopic(ctx, &tipspb.DeleteTopicRequest{Topic: "t8"})
+	assertGRPCCode(t, codes.NotFound, err)
+}
+
+func TestGRPCStreamingPull(t *testing.T) {
+	client, closer := dialGRPC(t)
+	defer closer()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	topic, err := client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t9", Partitions: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), topic.Partitions)
+	_, err = client.CreateTopic(ctx, &tipspb.CreateTopicRequest{Topic: "t9", Partitions: 2})
+	assertGRPCCode(t, codes.InvalidArgument, err)
+	stream, err := client.StreamingPull(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&tipspb.StreamingPullRequest{Topic: "t9", Subscription: "s1"}))
+	_, err = stream.Recv()
+	assertGRPCCode(t, codes.NotFound, err)
+
+	_, err = client.Subscribe(ctx, &tipspb.SubscribeRequest{Topic: "t9", Subscription: "s1"})
+	require.NoError(t, err)
+	stream, err = client.StreamingPull(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&tipspb.StreamingPullRequest{Topic: "t9", Subscription: "s1", MaxOutstanding: 1}))
+
+	pub, err := client.Publish(ctx, &tipspb.PublishRequest{Topic: "t9", Messages: []*tipspb.Message{
+		{Payload: []byte("hello")},
+		{Payload: []byte("tips")},
+	}})
+	require.NoError(t, err)
+
+	// Only one message is outstanding at a time
+	var received []string
+	for i := 0; i < 2; i++ {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, resp.Messages, 1)
+		received = append(received, resp.Messages[0].Id)
+		require.NoError(t, stream.Send(&tipspb.StreamingPullRequest{AckIds: []string{resp.Messages[0].Id}}))
+	}
+	assert.Equal(t, pub.MessageIds, received)
+
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
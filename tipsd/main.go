@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -24,9 +25,13 @@ import (
 func main() {
 	var confPath string
 	var pdAddrs string
+	var migrate bool
+	var migrateBatch int
 
 	flag.StringVar(&confPath, "c", "conf/tips.toml", "conf file path")
 	flag.StringVar(&pdAddrs, "pd-addrs", "", "pd cluster addresses")
+	flag.BoolVar(&migrate, "migrate", false, "migrate the stored values to the binary format and exit")
+	flag.IntVar(&migrateBatch, "migrate-batch", 256, "max keys to migrate in a transaction")
 	flag.Parse()
 
 	config := &conf.Tips{}
@@ -56,7 +61,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	if migrate {
+		n, err := tips.Migrate(context.Background(), migrateBatch)
+		if err != nil {
+			fmt.Printf("migrate failed after %d values migrated, %s\n", n, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d values migrated\n", n)
+		return
+	}
+
+	tips.SetDedupWindow(config.Dedup.Window)
+	if config.GC.Enable {
+		tips.StartGC(context.Background(), config.GC.Interval, config.GC.BatchSize)
+	}
+	if config.Trimmer.Enable {
+		tips.StartTrimmer(context.Background(), config.Trimmer.Interval, config.Trimmer.BatchSize)
+	}
+	if config.Scheduler.Enable {
+		tips.StartScheduler(context.Background(), config.Scheduler.Interval, config.Scheduler.BatchSize)
+	}
+
+	if config.Notifier.Enable {
+		tips.SetNodeID(nodeID(&config.Notifier, &config.Server))
+		tips.StartNotifier(context.Background(), config.Notifier.Interval)
+	}
+	if config.Push.Enable {
+		go NewPusher(&config.Push, tips).Run(context.Background())
+	}
+	if config.Status.StatsInterval > 0 {
+		go metrics.NewCollector(&config.Status, tips.Stats).Run(context.Background())
+	}
+
 	serv := NewServer(&config.Server, tips)
+	gserv, err := NewGRPCServer(&config.Server, tips)
+	if err != nil {
+		zap.L().Fatal("create grpc server failed", zap.Error(err))
+	}
 	svr := metrics.NewServer(&config.Status)
 
 	writer, err := Writer(config.Logger.Path, config.Logger.TimeRotate, config.Logger.Compress)
@@ -68,6 +109,10 @@ func main() {
 		zap.L().Fatal("add tips server failed:", zap.Error(err))
 	}
 
+	if err := cont.AddServer(gserv, &continuous.ListenOn{Network: "tcp", Address: config.Server.GRPCListen}); err != nil {
+		zap.L().Fatal("add tips grpc server failed:", zap.Error(err))
+	}
+
 	if err := cont.AddServer(svr, &continuous.ListenOn{Network: "tcp", Address: config.Status.Listen}); err != nil {
 		zap.L().Fatal("add statues server failed:", zap.Error(err))
 	}
@@ -77,6 +122,19 @@ func main() {
 	}
 }
 
+// nodeID returns the id of this node in the versions of topics, the hostname and the
+// listen address are stable across restarts and unique among nodes if it is not given
+func nodeID(n *conf.Notifier, s *conf.Server) string {
+	if n.NodeID != "" {
+		return n.NodeID
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		zap.L().Warn("get hostname failed", zap.Error(err))
+	}
+	return host + "/" + s.Listen
+}
+
 // ConfigureZap customize the zap logger
 func ConfigureZap(name, path, level, pattern string, compress bool) error {
 	writer, err := Writer(path, pattern, compress)
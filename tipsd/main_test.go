@@ -15,6 +15,8 @@ var addr = "127.0.0.1:12345"
 
 var url = "http://127.0.0.1:12345"
 
+var grpcAddr = "127.0.0.1:12346"
+
 func TestMain(m *testing.M) {
 	conf := &conf.Server{}
 	pubsub, _ := tips.MockTips()
@@ -24,6 +26,16 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 	go server.Serve(lis)
+
+	gserver, err := NewGRPCServer(conf, pubsub)
+	if err != nil {
+		log.Fatal(err)
+	}
+	glis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go gserver.Serve(glis)
 	time.Sleep(time.Second)
 	v := m.Run()
 	os.Exit(v)
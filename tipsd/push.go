@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tipsio/tips"
+	"github.com/tipsio/tips/conf"
+	"github.com/tipsio/tips/metrics"
+	"go.uber.org/zap"
+)
+
+// PushRequest is the body posted to the endpoint of a push subscription
+type PushRequest struct {
+	Topic        string
+	Subscription string
+	Messages     []*tips.Message
+}
+
+// Pusher delivers the messages of push subscriptions to their endpoints,
+// a subscription is delivered by a worker which pulls messages, posts them
+// in batches and acks them once the endpoint responds with 2xx
+type Pusher struct {
+	pubsub *tips.Tips
+	conf   *conf.Push
+	client *http.Client
+
+	wg      sync.WaitGroup
+	workers map[string]*pushWorker
+}
+
+// pushWorker delivers the messages of a subscription
+type pushWorker struct {
+	topic    string
+	subName  string
+	endpoint string
+	cancel   context.CancelFunc
+}
+
+// NewPusher creates a pusher
+func NewPusher(conf *conf.Push, pubsub *tips.Tips) *Pusher {
+	return &Pusher{
+		pubsub:  pubsub,
+		conf:    conf,
+		client:  &http.Client{Timeout: conf.Timeout},
+		workers: make(map[string]*pushWorker),
+	}
+}
+
+// Run discovers push subscriptions every interval and delivers them until the ctx is done
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.conf.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.discover(ctx); err != nil {
+			zap.L().Error("discover push subscriptions failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			for _, w := range p.workers {
+				w.cancel()
+			}
+			p.wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discover starts workers for new push subscriptions and stops the workers
+// of subscriptions which are deleted or whose endpoint is changed
+func (p *Pusher) discover(ctx context.Context) error {
+	subs, err := p.pubsub.PushSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]*tips.PushSubscription)
+	for _, sub := range subs {
+		found[sub.Topic+"/"+sub.Name] = sub
+	}
+	for key, w := range p.workers {
+		if sub, ok := found[key]; !ok || sub.PushEndpoint != w.endpoint {
+			w.cancel()
+			delete(p.workers, key)
+		}
+	}
+	for key, sub := range found {
+		if _, ok := p.workers[key]; ok {
+			continue
+		}
+		wctx, cancel := context.WithCancel(ctx)
+		w := &pushWorker{topic: sub.Topic, subName: sub.Name, endpoint: sub.PushEndpoint, cancel: cancel}
+		p.workers[key] = w
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.deliver(wctx, w)
+		}()
+	}
+	return nil
+}
+
+// deliver pulls and pushes the messages of a subscription until the ctx is done,
+// a failed batch is nacked and retried with exponential backoff
+func (p *Pusher) deliver(ctx context.Context, w *pushWorker) {
+	watcher, err := p.pubsub.Watch(ctx, w.topic)
+	if err != nil {
+		zap.L().Error("watch push subscription failed", zap.String("topic", w.topic),
+			zap.String("subscription", w.subName), zap.Error(err))
+		return
+	}
+	defer watcher.Close()
+
+	var backoff time.Duration
+	for {
+		published := watcher.C()
+		n, err := p.deliverBatch(ctx, w)
+		// An idle worker is woken up once messages are published, while a
+		// failed one waits for the backoff
+		wait, wakeup := p.conf.PollInterval, published
+		if err != nil {
+			zap.L().Warn("push messages failed", zap.String("topic", w.topic),
+				zap.String("subscription", w.subName), zap.Error(err))
+			if backoff == 0 {
+				backoff = p.conf.MinBackoff
+			} else if backoff *= 2; backoff > p.conf.MaxBackoff {
+				backoff = p.conf.MaxBackoff
+			}
+			wait, wakeup = backoff, nil
+		} else {
+			backoff = 0
+			if n > 0 {
+				wait = 0
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wakeup:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// deliverBatch pushes a batch of messages, returns the number of pushed messages
+func (p *Pusher) deliverBatch(ctx context.Context, w *pushWorker) (int, error) {
+	msgs, err := p.pubsub.Pull(ctx, &tips.PullReq{
+		SubName: w.subName,
+		Topic:   w.topic,
+		Limit:   int64(p.conf.BatchSize),
+		// Leave enough time to ack the messages after the request is done
+		AckDeadline: 2 * p.conf.Timeout,
+	})
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+
+	start := time.Now()
+	err = p.push(ctx, w, msgs)
+	metrics.GetMetrics().PushRequestsHistogramVec.WithLabelValues(w.topic, w.subName).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.GetMetrics().PushMessagesCounterVec.WithLabelValues(w.topic, w.subName, "failed").Add(float64(len(msgs)))
+		// Make the messages redelivered immediately after the backoff
+		for _, msg := range msgs {
+			if err := p.pubsub.Nack(ctx, msg.ID, w.topic, w.subName); err != nil && tips.ErrorCode(err) != tips.NotFound {
+				zap.L().Error("nack pushed message failed", zap.String("id", msg.ID), zap.Error(err))
+			}
+		}
+		return 0, err
+	}
+
+	metrics.GetMetrics().PushMessagesCounterVec.WithLabelValues(w.topic, w.subName, "acked").Add(float64(len(msgs)))
+	for _, msg := range msgs {
+		if err := p.pubsub.Ack(ctx, msg.ID, w.topic, w.subName); err != nil {
+			return 0, err
+		}
+	}
+	return len(msgs), nil
+}
+
+// push posts messages to the endpoint, it fails if the response is not 2xx
+func (p *Pusher) push(ctx context.Context, w *pushWorker, msgs []*tips.Message) error {
+	body, err := json.Marshal(&PushRequest{Topic: w.topic, Subscription: w.subName, Messages: msgs})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	// Drain the body so the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responds with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tipsio/tips"
+	"github.com/tipsio/tips/conf"
+)
+
+// endpoint is a push endpoint which fails the first failures requests
+type endpoint struct {
+	sync.Mutex
+	failures int
+	requests []*PushRequest
+	received chan struct{}
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &PushRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.Lock()
+	defer e.Unlock()
+	e.requests = append(e.requests, req)
+	if len(e.requests) <= e.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	e.received <- struct{}{}
+}
+
+func TestPusher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubsub, err := tips.MockTips()
+	assert.NoError(t, err)
+	_, err = pubsub.CreateTopic(ctx, "t1")
+	assert.NoError(t, err)
+	_, err = pubsub.Subscribe(ctx, "s1", "t1", nil)
+	assert.NoError(t, err)
+
+	_, err = pubsub.SetPushEndpoint(ctx, "s1", "t1", "ftp://localhost")
+	assert.Error(t, err)
+
+	e := &endpoint{failures: 2, received: make(chan struct{}, 1)}
+	server := httptest.NewServer(e)
+	defer server.Close()
+	_, err = pubsub.SetPushEndpoint(ctx, "s1", "t1", server.URL)
+	assert.NoError(t, err)
+	msgid, err := pubsub.Publish(ctx, []string{"hello tips1", "hello tips2"}, "t1")
+	assert.NoError(t, err)
+
+	pusher := NewPusher(&conf.Push{
+		Interval:     time.Second,
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    10,
+		Timeout:      time.Second,
+		MinBackoff:   10 * time.Millisecond,
+		MaxBackoff:   20 * time.Millisecond,
+	}, pubsub)
+	done := make(chan struct{})
+	go func() {
+		pusher.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-e.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages are not pushed")
+	}
+
+	e.Lock()
+	assert.Len(t, e.requests, 3)
+	for i, req := range e.requests {
+		assert.Equal(t, "t1", req.Topic)
+		assert.Equal(t, "s1", req.Subscription)
+		if assert.Len(t, req.Messages, 2) {
+			assert.Equal(t, msgid[0], req.Messages[0].ID)
+			assert.Equal(t, "hello tips2", string(req.Messages[1].Payload))
+			// The failed messages are redelivered
+			assert.Equal(t, i+1, req.Messages[0].DeliveryAttempt)
+		}
+	}
+	e.Unlock()
+
+	// The pushed messages are acked
+	acked := false
+	for i := 0; i < 100 && !acked; i++ {
+		sub, err := pubsub.Subscribe(ctx, "s1", "t1", nil)
+		assert.NoError(t, err)
+		acked = sub.Acked.String() == msgid[1]
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, acked)
+
+	cancel()
+	<-done
+}
+
+func TestPushEndpoint(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t6", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t6/s1", "PUT", strings.NewReader(`{"pushendpoint":"localhost/push"}`))
+	assertCodeBadRequest(t, code)
+	code, body := makeRequest(t, url+"/v1/subscriptions/t6/s1", "PUT", strings.NewReader(`{"pushendpoint":"http://localhost/push"}`))
+	assertCodeOK(t, code)
+	sub := &tips.Subscription{}
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Equal(t, "http://localhost/push", sub.PushEndpoint)
+
+	// The push endpoint is kept if it is not given, and an empty one makes it a pull subscription
+	code, body = makeRequest(t, url+"/v1/subscriptions/t6/s1", "PUT", strings.NewReader(`{}`))
+	assertCodeOK(t, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Equal(t, "http://localhost/push", sub.PushEndpoint)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t6/s1", "PUT", strings.NewReader(`{"pushendpoint":""}`))
+	assertCodeOK(t, code)
+	sub = &tips.Subscription{}
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Empty(t, sub.PushEndpoint)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t6", "DELETE", nil)
+	assertCodeOK(t, code)
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -46,20 +46,27 @@ func NewServer(conf *conf.Server, pubsub *tips.Tips) *Server {
 func (s *Server) initRouter() {
 	// s.router.Use(AccessLoggerFunc(zap.L()), gin.Recovery())
 	s.router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"Reason": "tips: Page not found. Resource you request may not exist."})
+		reply(c, tips.NotFound, errors.New("tips: Page not found. Resource you request may not exist."))
 	})
+	s.router.GET("/v1/topics", s.ListTopics)
 	s.router.PUT("/v1/topics/:topic", s.CreateTopic)
 	s.router.GET("/v1/topics/:topic", s.Topic)
 	s.router.DELETE("/v1/topics/:topic", s.Destroy)
 
 	s.router.POST("/v1/messages/topics/:topic", s.Publish)
 	s.router.POST("/v1/messages/ack/:topic/:subname/:msgid", s.Ack)
+	s.router.POST("/v1/messages/nack/:topic/:subname/:msgid", s.Nack)
+	s.router.POST("/v1/messages/deadline/:topic/:subname/:msgid", s.ModifyAckDeadline)
 
 	s.router.PUT("/v1/subscriptions/:topic/:subname", s.Subscribe)
 	s.router.DELETE("/v1/subscriptions/:topic/:subname", s.Unsubscribe)
-	// s.router.GET("/v1/subscriptions/:subname", s.Subscription)
+	s.router.GET("/v1/subscriptions/:topic", s.ListSubscriptions)
+	s.router.GET("/v1/subscriptions/:topic/:subname", s.Subscription)
 	s.router.POST("/v1/subscriptions/:topic/:subname", s.Pull)
+	s.router.GET("/v1/subscriptions/:topic/:subname/stream", s.StreamingPull)
+	s.router.POST("/v1/subscriptions/:topic/:subname/seek", s.SeekTo)
 
+	s.router.GET("/v1/snapshots/:topic/:subname", s.ListSnapshots)
 	s.router.PUT("/v1/snapshots/:topic/:subname/:name", s.CreateSnapshots)
 	s.router.DELETE("/v1/snapshots/:topic/:subname/:name", s.DeleteSnapshots)
 	s.router.POST("/v1/snapshots/:topic/:subname/:name", s.Seek)
@@ -91,40 +98,42 @@ func (s *Server) GracefulStop() error {
 	return s.httpServer.Shutdown(ctx)
 }
 
-func (t *Server) pull(ctx context.Context, req *tips.PullReq, timeout time.Duration) ([]*tips.Message, error) {
-	tick := time.Tick(timeout)
-	var msgs []*tips.Message
-	var err error
-	// when no data is available, try again every 100ms until the tick is out.
-	for {
-		select {
-		case <-tick:
-			return msgs, nil
-		default:
-			msgs, err = t.pubsub.Pull(ctx, req)
-			if err != nil {
-				return nil, err
-			}
-		}
-		if len(msgs) != 0 {
-			break
-		}
-		time.Sleep(time.Millisecond * 100)
+// waitPull waits until there are messages or the ctx is done, an empty result is returned on timeout
+func waitPull(ctx context.Context, pubsub *tips.Tips, req *tips.PullReq) ([]*tips.Message, error) {
+	req.Wait = true
+	msgs, err := pubsub.Pull(ctx, req)
+	if err == context.DeadlineExceeded {
+		return nil, nil
 	}
-	return msgs, nil
+	return msgs, err
 }
 
-// ErrNotFound returns true if the error is caused by resource missing
-func ErrNotFound(err error) bool {
-	return strings.Contains(err.Error(), "not found")
+// httpStatus maps the codes of the errors returned by tips to http status
+var httpStatus = map[tips.Code]int{
+	tips.Internal:        http.StatusInternalServerError,
+	tips.NotFound:        http.StatusNotFound,
+	tips.AlreadyExists:   http.StatusConflict,
+	tips.Conflict:        http.StatusConflict,
+	tips.InvalidArgument: http.StatusBadRequest,
+	tips.Unavailable:     http.StatusServiceUnavailable,
 }
 
-// Error wraps a http server error
+// Error wraps a http server error, Code is the machine-readable tips.Code of it
 type Error struct {
+	Code   string `json:"code"`
 	Reason string `json:"reason"`
 }
 
-func fail(c *gin.Context, httpStatus int, err error) {
-	e := Error{Reason: err.Error()}
-	c.JSON(httpStatus, e)
+// fail replies an error returned by tips with the status of its code
+func fail(c *gin.Context, err error) {
+	reply(c, tips.ErrorCode(err), err)
+}
+
+// badRequest replies an error of a malformed request
+func badRequest(c *gin.Context, err error) {
+	reply(c, tips.InvalidArgument, err)
+}
+
+func reply(c *gin.Context, code tips.Code, err error) {
+	c.JSON(httpStatus[code], &Error{Code: code.String(), Reason: err.Error()})
 }
@@ -51,6 +51,19 @@ func EndMessageID(body string) string {
 	return msgs[len(msgs)-1].ID
 }
 
+func MessageIDs(body string) []string {
+	msgs := []*struct {
+		Payload []byte
+		ID      string
+	}{}
+	json.Unmarshal([]byte(body), &msgs)
+	var ids []string
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
 func assertCodeNotFound(t testing.TB, code int) {
 	assert.Equal(t, http.StatusNotFound, code, "Unexpected response status code.")
 }
@@ -122,8 +135,10 @@ func TestNormal(t *testing.T) {
 	assertCodeOK(t, code)
 	assertBodyLen(t, body, 3, "3")
 
-	code, body = makeRequest(t, url+"/v1/messages/ack/t1/s1/"+EndMessageID(body), "POST", nil)
-	assertCodeOK(t, code)
+	for _, id := range MessageIDs(body) {
+		code, _ = makeRequest(t, url+"/v1/messages/ack/t1/s1/"+id, "POST", nil)
+		assertCodeOK(t, code)
+	}
 
 	method = fmt.Sprintf(`{"autoack":true,"limit":3}`)
 	code, body = makeRequest(t, url+"/v1/subscriptions/t1/s1", "POST", strings.NewReader(method))
@@ -181,6 +196,18 @@ func TestIllagel(t *testing.T) {
 	code, body = makeRequest(t, url+"/v1/snapshots/t1/s1/shot", "DELETE", nil)
 	assertCodeNotFound(t, code)
 	assert.Contains(t, body, "not found")
+
+	code, body = makeRequest(t, url+"/v1/topics/t1", "DELETE", nil)
+	assertCodeNotFound(t, code)
+	assert.Contains(t, body, `"code":"not_found"`)
+
+	code, body = makeRequest(t, url+"/v1/topics?limit=x", "GET", nil)
+	assertCodeBadRequest(t, code)
+	assert.Contains(t, body, `"code":"invalid_argument"`)
+
+	code, body = makeRequest(t, url+"/v1/topics?token=!", "GET", nil)
+	assertCodeBadRequest(t, code)
+	assert.Contains(t, body, `"code":"invalid_argument"`)
 }
 
 func TestPull(t *testing.T) {
@@ -206,3 +233,402 @@ func TestPull(t *testing.T) {
 	code, body = makeRequest(t, url+"/v1/topics/t1", "DELETE", nil)
 	assertCodeOK(t, code)
 }
+
+func TestRetention(t *testing.T) {
+	code, body := makeRequest(t, url+"/v1/topics/t1", "PUT", strings.NewReader(`{"retention":{"maxage":3600,"maxcount":100,"acked":true}}`))
+	assertCodeOK(t, code)
+	topic := &tips.Topic{}
+	assert.NoError(t, json.Unmarshal([]byte(body), topic))
+	assert.NotNil(t, topic.Retention)
+	assert.Equal(t, time.Hour, topic.Retention.MaxAge)
+	assert.Equal(t, int64(100), topic.Retention.MaxCount)
+	assert.True(t, topic.Retention.Acked)
+
+	code, body = makeRequest(t, url+"/v1/topics/t1", "PUT", strings.NewReader(`{"retention":{"maxcount":-1}}`))
+	assertCodeBadRequest(t, code)
+
+	code, body = makeRequest(t, url+"/v1/topics/t1", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestNack(t *testing.T) {
+	code, body := makeRequest(t, url+"/v1/topics/t2", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t2/s1", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/messages/topics/t2", "POST", strings.NewReader(`{"messages":["h"]}`))
+	assertCodeOK(t, code)
+
+	code, body = makeRequest(t, url+"/v1/subscriptions/t2/s1", "POST", strings.NewReader(`{"limit":1,"ackdeadline":3600}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "h")
+	id := EndMessageID(body)
+
+	code, body = makeRequest(t, url+"/v1/messages/nack/t2/s1/"+id, "POST", nil)
+	assertCodeOK(t, code)
+
+	code, body = makeRequest(t, url+"/v1/subscriptions/t2/s1", "POST", strings.NewReader(`{"limit":1,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "h")
+
+	code, body = makeRequest(t, url+"/v1/messages/deadline/t2/s1/"+id, "POST", strings.NewReader(`{"deadline":3600}`))
+	assertCodeOK(t, code)
+
+	code, body = makeRequest(t, url+"/v1/messages/ack/t2/s1/"+id, "POST", nil)
+	assertCodeOK(t, code)
+
+	code, body = makeRequest(t, url+"/v1/messages/nack/t2/s1/"+id, "POST", nil)
+	assertCodeNotFound(t, code)
+
+	code, body = makeRequest(t, url+"/v1/topics/t2", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestDeadLetter(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t3", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t3/s1", "PUT", strings.NewReader(`{"deadlettertopic":"dlt","maxdeliveryattempts":3}`))
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t3/s1", "PUT", strings.NewReader(`{"deadlettertopic":"dlt"}`))
+	assertCodeBadRequest(t, code)
+
+	code, _ = makeRequest(t, url+"/v1/topics/dlt", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body := makeRequest(t, url+"/v1/subscriptions/t3/s1", "PUT", strings.NewReader(`{"deadlettertopic":"dlt","maxdeliveryattempts":3}`))
+	assertCodeOK(t, code)
+	sub := &tips.Subscription{}
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Equal(t, "dlt", sub.DeadLetterTopic)
+	assert.Equal(t, 3, sub.MaxDeliveryAttempts)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t3", "DELETE", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/topics/dlt", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestAttributes(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t4", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t4/s1", "PUT", nil)
+	assertCodeOK(t, code)
+
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST", strings.NewReader(`{"messages":[{"attributes":{"k":"v"}}]}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST", strings.NewReader(`{"messages":[1]}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST",
+		strings.NewReader(`{"messages":["h1",{"payload":"h2","attributes":{"content-type":"text/plain"}}]}`))
+	assertCodeOK(t, code)
+
+	code, body := makeRequest(t, url+"/v1/subscriptions/t4/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true}`))
+	assertCodeOK(t, code)
+	msgs := []*tips.Message{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &msgs))
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, "h1", string(msgs[0].Payload))
+	assert.Nil(t, msgs[0].Attributes)
+	assert.Equal(t, "h2", string(msgs[1].Payload))
+	assert.Equal(t, map[string]string{"content-type": "text/plain"}, msgs[1].Attributes)
+	assert.NotZero(t, msgs[1].PublishTime)
+
+	// The payloads which are not valid UTF-8 are published in base64
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST", strings.NewReader(`{"messages":[{"payload":"h3","payloadBase64":"/wA="}]}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST", strings.NewReader(`{"messages":[{"payloadBase64":"!"}]}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t4", "POST", strings.NewReader(`{"messages":[{"payloadBase64":"/wA="}]}`))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t4/s1", "POST", strings.NewReader(`{"limit":1,"autoack":true}`))
+	assertCodeOK(t, code)
+	msgs = []*tips.Message{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &msgs))
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, []byte{0xff, 0x00}, msgs[0].Payload)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t4", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestFilter(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t5", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t5/s1", "PUT", strings.NewReader(`{"filter":"attributes.type ="}`))
+	assertCodeBadRequest(t, code)
+	code, body := makeRequest(t, url+"/v1/subscriptions/t5/s1", "PUT", strings.NewReader(`{"filter":"attributes.type = \"order\""}`))
+	assertCodeOK(t, code)
+	sub := &tips.Subscription{}
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Equal(t, `attributes.type = "order"`, sub.Filter)
+
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t5", "POST",
+		strings.NewReader(`{"messages":["h1",{"payload":"h2","attributes":{"type":"order"}}]}`))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t5/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "h2")
+
+	// An empty filter clears it, the filter is kept if it is not given
+	code, body = makeRequest(t, url+"/v1/subscriptions/t5/s1", "PUT", strings.NewReader(`{}`))
+	assertCodeOK(t, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Equal(t, `attributes.type = "order"`, sub.Filter)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t5/s1", "PUT", strings.NewReader(`{"filter":""}`))
+	assertCodeOK(t, code)
+	sub = &tips.Subscription{}
+	assert.NoError(t, json.Unmarshal([]byte(body), sub))
+	assert.Empty(t, sub.Filter)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t5", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestList(t *testing.T) {
+	for _, topic := range []string{"t12-a", "t12-b", "t12-c"} {
+		code, _ := makeRequest(t, url+"/v1/topics/"+topic, "PUT", nil)
+		assertCodeOK(t, code)
+	}
+	page := &struct {
+		Topics    []*tips.Topic
+		NextToken string
+	}{}
+	code, body := makeRequest(t, url+"/v1/topics?prefix=t12-&limit=2", "GET", nil)
+	assertCodeOK(t, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), page))
+	assert.Len(t, page.Topics, 2)
+	assert.Equal(t, "t12-a", page.Topics[0].Name)
+	code, body = makeRequest(t, url+"/v1/topics?prefix=t12-&limit=2&token="+page.NextToken, "GET", nil)
+	assertCodeOK(t, code)
+	assert.NoError(t, json.Unmarshal([]byte(body), page))
+	assert.Len(t, page.Topics, 1)
+	assert.Equal(t, "t12-c", page.Topics[0].Name)
+	assert.Empty(t, page.NextToken)
+	code, _ = makeRequest(t, url+"/v1/topics?limit=-1", "GET", nil)
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/topics?token=!", "GET", nil)
+	assertCodeBadRequest(t, code)
+
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t12-d", "GET", nil)
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t12-a/s1", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t12-a", "GET", nil)
+	assertCodeOK(t, code)
+	subs := &struct {
+		Subscriptions []*tips.Subscription
+		NextToken     string
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(body), subs))
+	assert.Len(t, subs.Subscriptions, 1)
+	assert.Equal(t, "s1", subs.Subscriptions[0].Name)
+
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t12-a/s2", "GET", nil)
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t12-a", "POST", strings.NewReader(`{"messages":["hello"]}`))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t12-a/s1", "GET", nil)
+	assertCodeOK(t, code)
+	info := &tips.SubscriptionInfo{}
+	assert.NoError(t, json.Unmarshal([]byte(body), info))
+	assert.Equal(t, "s1", info.Name)
+	assert.Equal(t, int64(1), info.Unacked)
+	assert.Equal(t, int64(1), info.Undelivered)
+	assert.Equal(t, info.OldestUnacked, info.Head)
+
+	code, _ = makeRequest(t, url+"/v1/snapshots/t12-a/s2", "GET", nil)
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/snapshots/t12-a/s1/snap", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/snapshots/t12-a/s1", "GET", nil)
+	assertCodeOK(t, code)
+	snapshots := &struct {
+		Snapshots []*tips.Snapshot
+		NextToken string
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(body), snapshots))
+	assert.Len(t, snapshots.Snapshots, 1)
+	assert.Equal(t, "snap", snapshots.Snapshots[0].Name)
+
+	for _, topic := range []string{"t12-a", "t12-b", "t12-c"} {
+		code, _ := makeRequest(t, url+"/v1/topics/"+topic, "DELETE", nil)
+		assertCodeOK(t, code)
+	}
+}
+
+func TestSeekTo(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t16", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body := makeRequest(t, url+"/v1/messages/topics/t16", "POST", strings.NewReader(`{"messages":["h1","h2"]}`))
+	assertCodeOK(t, code)
+	var ids []string
+	assert.NoError(t, json.Unmarshal([]byte(body), &ids))
+	code, body = makeRequest(t, url+"/v1/subscriptions/t16/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 2, "h2")
+
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1/seek", "POST", strings.NewReader(`{}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1/seek", "POST", strings.NewReader(`{"offset":"garbage"}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/ack/t16/s1/garbage", "POST", nil)
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1", "POST", strings.NewReader(`{"offset":"garbage","timeout":1}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s2/seek", "POST", strings.NewReader(`{"offset":"`+ids[0]+`"}`))
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1/seek", "POST", strings.NewReader(`{"offset":"`+ids[0]+`"}`))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t16/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "h2")
+
+	since := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t16/s1/seek", "POST", strings.NewReader(`{"time":"`+since+`"}`))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t16/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 2, "h2")
+
+	code, _ = makeRequest(t, url+"/v1/topics/t16", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestStartPosition(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t18", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t18", "POST", strings.NewReader(`{"messages":["h1","h2"]}`))
+	assertCodeOK(t, code)
+
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t18/s1", "PUT", strings.NewReader(`{"start":{"earliest":true,"offset":"1-0"}}`))
+	assertCodeBadRequest(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t18/s1", "PUT", strings.NewReader(`{"start":{"snapshot":"snap","snapshotsubscription":"s0"}}`))
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t18/s1", "PUT", strings.NewReader(`{"start":{"earliest":true}}`))
+	assertCodeOK(t, code)
+	code, body := makeRequest(t, url+"/v1/subscriptions/t18/s1", "POST", strings.NewReader(`{"limit":2,"autoack":true,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 2, "h2")
+
+	code, _ = makeRequest(t, url+"/v1/topics/t18", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestPublishDedup(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t19", "PUT", nil)
+	assertCodeOK(t, code)
+
+	msgs := `{"messages":[{"payload":"h1","dedupKey":"k1"},"h2"]}`
+	code, body := makeRequest(t, url+"/v1/messages/topics/t19", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+	var ids []string
+	require.NoError(t, json.Unmarshal([]byte(body), &ids))
+	require.Len(t, ids, 2)
+	// A retried request returns the original id of the message with the dedup key
+	code, body = makeRequest(t, url+"/v1/messages/topics/t19", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+	var retried []string
+	require.NoError(t, json.Unmarshal([]byte(body), &retried))
+	require.Len(t, retried, 2)
+	assert.Equal(t, ids[0], retried[0])
+	assert.NotEqual(t, ids[1], retried[1])
+
+	code, _ = makeRequest(t, url+"/v1/topics/t19", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestOrdered(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t20", "PUT", nil)
+	assertCodeOK(t, code)
+	code, body := makeRequest(t, url+"/v1/subscriptions/t20/s1", "PUT", strings.NewReader(`{"ordered":true}`))
+	assertCodeOK(t, code)
+	assert.Contains(t, body, `"Ordered":true`)
+
+	msgs := `{"messages":[{"payload":"a1","orderingKey":"a"},{"payload":"a2","orderingKey":"a"},{"payload":"b1","orderingKey":"b"}]}`
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t20", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+
+	code, body = makeRequest(t, url+"/v1/subscriptions/t20/s1", "POST", strings.NewReader(`{"limit":10,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 2, "b1")
+	ids := MessageIDs(body)
+	code, _ = makeRequest(t, url+"/v1/messages/ack/t20/s1/"+ids[0], "POST", nil)
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t20/s1", "POST", strings.NewReader(`{"limit":10,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "a2")
+	assert.Contains(t, body, `"OrderingKey":"a"`)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t20", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestDelayed(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t21", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t21/s1", "PUT", nil)
+	assertCodeOK(t, code)
+
+	msgs := `{"messages":[{"payload":"d1","delay":3600},{"payload":"d2","deliverAt":"2000-01-01T00:00:00Z"}]}`
+	code, body := makeRequest(t, url+"/v1/messages/topics/t21", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+	var ids []string
+	require.NoError(t, json.Unmarshal([]byte(body), &ids))
+	require.Len(t, ids, 2)
+
+	// Only the one due already is visible
+	code, body = makeRequest(t, url+"/v1/subscriptions/t21/s1", "POST", strings.NewReader(`{"limit":10,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "d2")
+
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t21", "POST", strings.NewReader(`{"messages":[{"payload":"d3","delay":-1}]}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t21", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestTTL(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t22", "PUT", strings.NewReader(`{"TTL":-1}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, body := makeRequest(t, url+"/v1/topics/t22", "PUT", strings.NewReader(`{"TTL":60}`))
+	assertCodeOK(t, code)
+	assert.Contains(t, body, `"TTL":60000000000`)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t22/s1", "PUT", nil)
+	assertCodeOK(t, code)
+
+	msgs := `{"messages":[{"payload":"e1","ttl":3600}]}`
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t22", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t22/s1", "POST", strings.NewReader(`{"limit":10,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 1, "e1")
+
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t22", "POST", strings.NewReader(`{"messages":[{"payload":"e2","ttl":-1}]}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t22", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+func TestPartitioned(t *testing.T) {
+	code, body := makeRequest(t, url+"/v1/topics/t23", "PUT", strings.NewReader(`{"Partitions":4}`))
+	assertCodeOK(t, code)
+	assert.Contains(t, body, `"Partitions":4`)
+	// The partitions can not be changed
+	code, _ = makeRequest(t, url+"/v1/topics/t23", "PUT", strings.NewReader(`{"Partitions":2}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t23/s1", "PUT", nil)
+	assertCodeOK(t, code)
+
+	msgs := `{"messages":["p1","p2",{"payload":"p3","orderingKey":"a"},"p4"]}`
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t23", "POST", strings.NewReader(msgs))
+	assertCodeOK(t, code)
+	code, body = makeRequest(t, url+"/v1/subscriptions/t23/s1", "POST", strings.NewReader(`{"limit":10,"timeout":1}`))
+	assertCodeOK(t, code)
+	assertBodyLen(t, body, 4, "p4")
+
+	code, _ = makeRequest(t, url+"/v1/topics/t23", "DELETE", nil)
+	assertCodeOK(t, code)
+}
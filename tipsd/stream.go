@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/tipsio/tips"
+	"github.com/tipsio/tips/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultMaxOutstanding is the default max number of messages sent but not acked in a stream
+	defaultMaxOutstanding = 1000
+	// defaultMaxOutstandingBytes is the default max bytes of payloads sent but not acked in a stream
+	defaultMaxOutstandingBytes = 10 << 20
+	// streamMaxRequestSize is the max size of a StreamRequest received
+	streamMaxRequestSize = 1 << 20
+	// streamCloseTimeout is the max duration to send the close message of a failed stream
+	streamCloseTimeout = time.Second
+)
+
+// upgrader upgrades the requests of streaming pulls, a 400 response is written if
+// a request is not a valid WebSocket handshake
+var upgrader = websocket.Upgrader{}
+
+// StreamRequest is sent by the client of a stream to ack or nack messages
+type StreamRequest struct {
+	Ack  []string
+	Nack []string
+}
+
+// streamConn is the connection which a stream is served on
+type streamConn interface {
+	// Send sends a batch of messages
+	Send(msgs []*tips.Message) error
+	// Recv receives the acks and nacks of the client
+	Recv() (*StreamRequest, error)
+	// Fail tells the client that the stream is ended by an error of the server
+	Fail(err error)
+}
+
+// stream delivers messages of a subscription over a connection with flow control
+type stream struct {
+	pubsub  *tips.Tips
+	conn    streamConn
+	topic   string
+	subName string
+
+	maxOutstanding      int
+	maxOutstandingBytes int
+	ackDeadline         time.Duration
+
+	mu          sync.Mutex
+	outstanding map[string]int
+	bytes       int
+	wakeup      chan struct{}
+}
+
+// newStream creates a stream of a subscription with the default flow control
+func newStream(pubsub *tips.Tips, topic, subName string) *stream {
+	return &stream{
+		pubsub:              pubsub,
+		topic:               topic,
+		subName:             subName,
+		maxOutstanding:      defaultMaxOutstanding,
+		maxOutstandingBytes: defaultMaxOutstandingBytes,
+		outstanding:         make(map[string]int),
+		wakeup:              make(chan struct{}, 1),
+	}
+}
+
+// StreamingPull streams the messages of a subscription over a WebSocket connection
+// Messages are sent in JSON arrays as they arrive, and the client acks or nacks them on the
+// same connection by sending a StreamRequest. Messages are not sent once the number or the
+// bytes of outstanding messages exceed the limits given by the query string:
+// maxoutstanding, maxoutstandingbytes and ackdeadline in seconds
+func (t *Server) StreamingPull(c *gin.Context) {
+	s := newStream(t.pubsub, c.Param("topic"), c.Param("subname"))
+	for name, v := range map[string]*int{
+		"maxoutstanding":      &s.maxOutstanding,
+		"maxoutstandingbytes": &s.maxOutstandingBytes,
+	} {
+		if q := c.Query(name); q != "" {
+			n, err := strconv.Atoi(q)
+			if err != nil || n <= 0 {
+				badRequest(c, errors.New(name+" should be a positive integer"))
+				return
+			}
+			*v = n
+		}
+	}
+	if q := c.Query("ackdeadline"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 0 {
+			badRequest(c, errors.New("ackdeadline should be a non-negative integer"))
+			return
+		}
+		s.ackDeadline = time.Duration(n) * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	// Watch and pull the first batch before upgrading, so that errors are responded in HTTP
+	w, err := t.pubsub.Watch(ctx, s.topic)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	defer w.Close()
+	published := w.C()
+	msgs, next, err := s.pull(ctx)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		s.nack(msgs)
+		return
+	}
+	conn.SetReadLimit(streamMaxRequestSize)
+	s.conn = &wsStream{conn}
+	defer conn.Close()
+
+	if err := s.serve(ctx, w, published, msgs, next); err != nil {
+		zap.L().Debug("stream ended", zap.Error(err))
+	}
+}
+
+// wsStream serves a stream over a WebSocket connection, messages are sent in JSON
+// arrays and the acks and nacks are received in JSON objects of StreamRequest.
+// Send is only called by the sender, the close messages are written by WriteControl
+// which is safe to be called concurrently with it.
+type wsStream struct {
+	conn *websocket.Conn
+}
+
+func (ws *wsStream) Send(msgs []*tips.Message) error {
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	return ws.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (ws *wsStream) Recv() (*StreamRequest, error) {
+	_, data, err := ws.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	req := &StreamRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		ws.close(websocket.CloseProtocolError, err.Error())
+		return nil, err
+	}
+	return req, nil
+}
+
+func (ws *wsStream) Fail(err error) {
+	ws.close(websocket.CloseInternalServerErr, err.Error())
+}
+
+// close sends a close message and closes the connection
+func (ws *wsStream) close(code int, reason string) {
+	// The reason of a close message is limited to 123 bytes
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	// The error is ignored because the connection is being closed anyway
+	ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(streamCloseTimeout))
+	ws.conn.Close()
+}
+
+// serve sends messages and handles the acks and nacks until the ctx is done or the
+// connection is broken, returns the error which ends the stream. The messages not acked
+// are redelivered immediately once it returns
+func (s *stream) serve(ctx context.Context, w *tips.Watcher, published <-chan struct{}, msgs []*tips.Message, next time.Time) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	received := make(chan error, 1)
+	go func() {
+		defer cancel()
+		received <- s.receive(ctx)
+	}()
+	err := s.send(ctx, w, published, msgs, next)
+	select {
+	case rerr := <-received:
+		// The stream is ended by the receiving
+		err = rerr
+	default:
+	}
+
+	s.mu.Lock()
+	var ids []string
+	for id := range s.outstanding {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.pubsub.Nack(context.Background(), id, s.topic, s.subName)
+	}
+	return err
+}
+
+// pull pulls the messages allowed by the flow control, and returns the time when the
+// earliest lease of the subscription expires, zero if there is none or nothing is pulled
+// because of the flow control
+func (s *stream) pull(ctx context.Context) ([]*tips.Message, time.Time, error) {
+	s.mu.Lock()
+	limit := s.maxOutstanding - len(s.outstanding)
+	if s.bytes >= s.maxOutstandingBytes {
+		limit = 0
+	}
+	s.mu.Unlock()
+	if limit <= 0 {
+		return nil, time.Time{}, nil
+	}
+
+	start := time.Now()
+	msgs, next, err := s.pubsub.TryPull(ctx, &tips.PullReq{
+		SubName:     s.subName,
+		Topic:       s.topic,
+		Limit:       int64(limit),
+		AckDeadline: s.ackDeadline,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("stream").Observe(time.Since(start).Seconds())
+
+	s.mu.Lock()
+	var size float64
+	for _, msg := range msgs {
+		// A message redelivered to the stream after its ack deadline is counted once
+		if size, ok := s.outstanding[msg.ID]; ok {
+			s.bytes -= size
+		}
+		s.outstanding[msg.ID] = len(msg.Payload)
+		s.bytes += len(msg.Payload)
+		size += float64(len(msg.Payload))
+	}
+	s.mu.Unlock()
+	if len(msgs) > 0 {
+		metrics.GetMetrics().MessagesSizeHistogramVec.WithLabelValues("stream").Observe(size)
+	}
+	return msgs, next, nil
+}
+
+// send sends messages until the ctx is done or the connection is broken, it pulls
+// again once messages are published, outstanding messages are released or the earliest
+// lease expires at next
+func (s *stream) send(ctx context.Context, w *tips.Watcher, published <-chan struct{}, msgs []*tips.Message, next time.Time) error {
+	for {
+		if len(msgs) > 0 {
+			if err := s.conn.Send(msgs); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wakeup:
+		case <-published:
+		case <-s.pubsub.RedeliveryAfter(next):
+		}
+
+		var err error
+		published = w.C()
+		msgs, next, err = s.pull(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.conn.Fail(err)
+			return err
+		}
+	}
+}
+
+// receive handles the acks and nacks sent by the client until the connection is closed
+func (s *stream) receive(ctx context.Context) error {
+	for {
+		req, err := s.conn.Recv()
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		for _, id := range req.Ack {
+			if err := s.pubsub.Ack(ctx, id, s.topic, s.subName); err != nil {
+				s.conn.Fail(err)
+				return err
+			}
+			s.release(id)
+		}
+		for _, id := range req.Nack {
+			if err := s.pubsub.Nack(ctx, id, s.topic, s.subName); err != nil && tips.ErrorCode(err) != tips.NotFound {
+				s.conn.Fail(err)
+				return err
+			}
+			s.release(id)
+		}
+		metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("stream-ack").Observe(time.Since(start).Seconds())
+	}
+}
+
+// release removes a message from the outstanding ones and wakes up the sender
+func (s *stream) release(id string) {
+	s.mu.Lock()
+	if size, ok := s.outstanding[id]; ok {
+		delete(s.outstanding, id)
+		s.bytes -= size
+	}
+	s.mu.Unlock()
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// nack makes the messages redelivered immediately
+func (s *stream) nack(msgs []*tips.Message) {
+	for _, msg := range msgs {
+		s.pubsub.Nack(context.Background(), msg.ID, s.topic, s.subName)
+	}
+}
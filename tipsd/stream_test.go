@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tipsio/tips"
+)
+
+// dialWebSocket connects to a WebSocket endpoint, the address is an http URL
+func dialWebSocket(t testing.TB, address string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(address, "http"), nil)
+	require.NoError(t, err)
+	return conn
+}
+
+func readMessages(t testing.TB, conn *websocket.Conn) []*tips.Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	op, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, op)
+	var msgs []*tips.Message
+	require.NoError(t, json.Unmarshal(data, &msgs))
+	return msgs
+}
+
+func closeWebSocket(conn *websocket.Conn) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	conn.Close()
+}
+
+func TestStreamingPull(t *testing.T) {
+	code, _ := makeRequest(t, url+"/v1/topics/t7", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t7/s1/stream", "GET", nil)
+	assertCodeNotFound(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t7/s1", "PUT", nil)
+	assertCodeOK(t, code)
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t7/s1/stream?maxoutstanding=0", "GET", nil)
+	assertCodeBadRequest(t, code)
+	// Not a WebSocket handshake
+	code, _ = makeRequest(t, url+"/v1/subscriptions/t7/s1/stream", "GET", nil)
+	assertCodeBadRequest(t, code)
+
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t7", "POST", strings.NewReader(`{"messages":["h1","h2","h3"]}`))
+	assertCodeOK(t, code)
+
+	conn := dialWebSocket(t, url+"/v1/subscriptions/t7/s1/stream?maxoutstanding=2&ackdeadline=3600")
+	msgs := readMessages(t, conn)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "h1", string(msgs[0].Payload))
+	assert.Equal(t, "h2", string(msgs[1].Payload))
+
+	// Nothing is sent before a message is acked, the reading is not timed out
+	// because a WebSocket connection can not be read again after a timeout
+	next := make(chan []*tips.Message, 1)
+	go func() {
+		next <- readMessages(t, conn)
+	}()
+	select {
+	case <-next:
+		t.Fatal("messages are sent beyond the max outstanding")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	data, err := json.Marshal(&StreamRequest{Ack: []string{msgs[0].ID}})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+	msgs = <-next
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "h3", string(msgs[0].Payload))
+
+	// The messages arriving later are streamed as well
+	data, err = json.Marshal(&StreamRequest{Ack: []string{msgs[0].ID}})
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+	code, _ = makeRequest(t, url+"/v1/messages/topics/t7", "POST", strings.NewReader(`{"messages":["h4"]}`))
+	assertCodeOK(t, code)
+	msgs = readMessages(t, conn)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "h4", string(msgs[0].Payload))
+	closeWebSocket(conn)
+
+	// The messages not acked are redelivered to the next stream
+	conn = dialWebSocket(t, url+"/v1/subscriptions/t7/s1/stream")
+	msgs = nil
+	for len(msgs) < 2 {
+		msgs = append(msgs, readMessages(t, conn)...)
+	}
+	require.Len(t, msgs, 2)
+	for _, msg := range msgs {
+		assert.Contains(t, []string{"h2", "h4"}, string(msg.Payload))
+		assert.Equal(t, 2, msg.DeliveryAttempt)
+	}
+	assert.NotEqual(t, msgs[0].ID, msgs[1].ID)
+	closeWebSocket(conn)
+
+	code, _ = makeRequest(t, url+"/v1/topics/t7", "DELETE", nil)
+	assertCodeOK(t, code)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestStreamRedelivery(t *testing.T) {
+	ctx := context.Background()
+	pubsub, err := tips.MockTips()
+	require.NoError(t, err)
+	clock := &fakeClock{now: time.Now()}
+	pubsub.SetClock(clock)
+	_, err = pubsub.CreateTopic(ctx, "t1")
+	require.NoError(t, err)
+	_, err = pubsub.Subscribe(ctx, "s1", "t1", nil)
+	require.NoError(t, err)
+	_, err = pubsub.Publish(ctx, []string{"hello"}, "t1")
+	require.NoError(t, err)
+
+	s := newStream(pubsub, "t1", "s1")
+	s.ackDeadline = time.Minute
+	msgs, _, err := s.pull(ctx)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 5, s.bytes)
+
+	// Redelivered to the same stream after the ack deadline, the bytes are counted once
+	clock.now = clock.now.Add(2 * time.Minute)
+	again, _, err := s.pull(ctx)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, msgs[0].ID, again[0].ID)
+	assert.Equal(t, 5, s.bytes)
+	s.release(again[0].ID)
+	assert.Equal(t, 0, s.bytes)
+	assert.Empty(t, s.outstanding)
+}
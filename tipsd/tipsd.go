@@ -2,32 +2,95 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tipsio/tips"
 	"github.com/tipsio/tips/metrics"
+	"github.com/tipsio/tips/store/pubsub"
 )
 
 // CreateTopic creates a topic that returns the client topic information
+// the retention policy and the TTL of the topic are updated if they are given
+// in the body, MaxAge and TTL are in seconds. The topic is partitioned if the
+// Partitions is given, which can not be changed later
 func (s *Server) CreateTopic(c *gin.Context) {
 	start := time.Now()
 	topic := c.Param("topic")
+	req := &struct {
+		Retention *struct {
+			MaxAge   int64
+			MaxCount int64
+			Acked    bool
+		}
+		TTL        *int64
+		Partitions int
+	}{}
+	// The body is optional
+	if err := c.ShouldBindJSON(req); err != nil && err != io.EOF {
+		badRequest(c, err)
+		return
+	}
+	var retention *pubsub.Retention
+	if r := req.Retention; r != nil {
+		var err error
+		if retention, err = newRetention(r.MaxAge, r.MaxCount, r.Acked); err != nil {
+			badRequest(c, err)
+			return
+		}
+	}
+	if req.TTL != nil && *req.TTL < 0 {
+		badRequest(c, errors.New("ttl should not be negative"))
+		return
+	}
 	ctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	t, err := s.pubsub.CreateTopic(ctx, topic)
+	var t *tips.Topic
+	var err error
+	if req.Partitions != 0 {
+		t, err = s.pubsub.CreatePartitionedTopic(ctx, topic, req.Partitions)
+	} else {
+		t, err = s.pubsub.CreateTopic(ctx, topic)
+	}
 	if err != nil {
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
+	if retention != nil {
+		if t, err = s.pubsub.SetRetention(ctx, topic, retention); err != nil {
+			fail(c, err)
+			return
+		}
+	}
+	if req.TTL != nil {
+		if t, err = s.pubsub.SetTTL(ctx, topic, time.Duration(*req.TTL)*time.Second); err != nil {
+			fail(c, err)
+			return
+		}
+	}
 	c.JSON(http.StatusOK, t)
 	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("create").Observe(time.Since(start).Seconds())
 	return
 }
 
+// newRetention creates a retention policy, maxAge is in seconds
+func newRetention(maxAge, maxCount int64, acked bool) (*pubsub.Retention, error) {
+	if maxAge < 0 || maxCount < 0 {
+		return nil, errors.New("retention should not be negative")
+	}
+	return &pubsub.Retention{
+		MaxAge:   time.Duration(maxAge) * time.Second,
+		MaxCount: maxCount,
+		Acked:    acked,
+	}, nil
+}
+
 // Topic returns a topic queried by name
 func (t *Server) Topic(c *gin.Context) {
 	start := time.Now()
@@ -36,17 +99,48 @@ func (t *Server) Topic(c *gin.Context) {
 	defer cancel()
 	msg, err := t.pubsub.Topic(ctx, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, msg)
 	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("topic").Observe(time.Since(start).Seconds())
 }
 
+// ListTopics lists the topics in pages, the query parameters are optional:
+// prefix filters the topics by name, token is the NextToken of the previous page
+// and limit is the max topics in a page
+func (t *Server) ListTopics(c *gin.Context) {
+	start := time.Now()
+	prefix, token, limit, err := listQuery(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	topics, next, err := t.pubsub.ListTopics(ctx, prefix, token, limit)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, &struct {
+		Topics    []*tips.Topic
+		NextToken string
+	}{topics, next})
+	metrics.GetMetrics().TopicsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+}
+
+// listQuery parses the query parameters of a list request
+func listQuery(c *gin.Context) (prefix string, token string, limit int, err error) {
+	if l := c.Query("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			return "", "", 0, errors.New("limit should be a positive integer")
+		}
+	}
+	return c.Query("prefix"), c.Query("token"), limit, nil
+}
+
 // Destroy deletes a topic
 func (t *Server) Destroy(c *gin.Context) {
 	start := time.Now()
@@ -54,7 +148,7 @@ func (t *Server) Destroy(c *gin.Context) {
 	ctx, cancel := context.WithCancel(t.ctx)
 	defer cancel()
 	if err := t.pubsub.Destroy(ctx, topic); err != nil {
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -64,41 +158,98 @@ func (t *Server) Destroy(c *gin.Context) {
 // Publish messages and return the allocated message ids for each
 // msgids msgids returns the same sequence as the outgoing message
 // forbidden topic and MSGS are not empty
+// A message is either a string of the payload or an object with the
+// payload and its attributes: {"payload":"...","attributes":{"k":"v"}}
 func (t *Server) Publish(c *gin.Context) {
 	start := time.Now()
 	topic := c.Param("topic")
 	pub := &struct {
-		Messages []string
+		Messages []json.RawMessage
 	}{}
 	if err := c.BindJSON(pub); err != nil {
-		fail(c, http.StatusBadRequest, err)
+		badRequest(c, err)
 		return
 	}
 	if len(pub.Messages) == 0 {
-		fail(c, http.StatusBadRequest, errors.New("msg is not null"))
+		badRequest(c, errors.New("msg is not null"))
 		return
 	}
+	msgs := make([]*tips.Message, len(pub.Messages))
+	for i, raw := range pub.Messages {
+		msg, err := parseMessage(raw)
+		if err != nil {
+			badRequest(c, err)
+			return
+		}
+		msgs[i] = msg
+	}
 	ctx, cancel := context.WithCancel(t.ctx)
 	defer cancel()
-	msgids, err := t.pubsub.Publish(ctx, pub.Messages, topic)
+	msgids, err := t.pubsub.PublishMessages(ctx, msgs, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, msgids)
 	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("publish").Observe(time.Since(start).Seconds())
 
 	var size float64
-	for _, msg := range pub.Messages {
-		size += float64(len(msg))
+	for _, msg := range msgs {
+		size += float64(len(msg.Payload))
 	}
 	metrics.GetMetrics().MessagesSizeHistogramVec.WithLabelValues("publish").Observe(size)
 }
 
+// parseMessage parses a published message in the form of a string or an object
+func parseMessage(raw json.RawMessage) (*tips.Message, error) {
+	var payload string
+	if err := json.Unmarshal(raw, &payload); err == nil {
+		return &tips.Message{Payload: []byte(payload)}, nil
+	}
+
+	msg := &struct {
+		Payload *string
+		// PayloadBase64 is the payload encoded in base64, which is not valid UTF-8
+		PayloadBase64 *string
+		Attributes    map[string]string
+		DedupKey      string
+		OrderingKey   string
+		DeliverAt     *time.Time
+		// Delay and TTL are in seconds
+		Delay int64
+		TTL   int64
+	}{}
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch {
+	case msg.Payload != nil && msg.PayloadBase64 != nil:
+		return nil, errors.New("only one of payload and payloadBase64 can be set")
+	case msg.Payload != nil:
+		data = []byte(*msg.Payload)
+	case msg.PayloadBase64 != nil:
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(*msg.PayloadBase64); err != nil {
+			return nil, errors.New("payloadBase64 is not valid base64")
+		}
+	default:
+		return nil, errors.New("payload is required")
+	}
+	m := &tips.Message{
+		Payload:     data,
+		Attributes:  msg.Attributes,
+		DedupKey:    msg.DedupKey,
+		OrderingKey: msg.OrderingKey,
+		Delay:       time.Duration(msg.Delay) * time.Second,
+		TTL:         time.Duration(msg.TTL) * time.Second,
+	}
+	if msg.DeliverAt != nil {
+		m.DeliverAt = msg.DeliverAt.UnixNano()
+	}
+	return m, nil
+}
+
 // Ack acknowledges a message
 func (t *Server) Ack(c *gin.Context) {
 	start := time.Now()
@@ -109,37 +260,182 @@ func (t *Server) Ack(c *gin.Context) {
 	defer cancel()
 	err := t.pubsub.Ack(ctx, msgid, topic, subName)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
 	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("ack").Observe(time.Since(start).Seconds())
 }
 
+// Nack tells that a message can not be processed now, it is redelivered on the next pull
+func (t *Server) Nack(c *gin.Context) {
+	start := time.Now()
+	subName := c.Param("subname")
+	topic := c.Param("topic")
+	msgid := c.Param("msgid")
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	err := t.pubsub.Nack(ctx, msgid, topic, subName)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("nack").Observe(time.Since(start).Seconds())
+}
+
+// ModifyAckDeadline resets the ack deadline of a pulled message
+// the deadline is in seconds
+func (t *Server) ModifyAckDeadline(c *gin.Context) {
+	start := time.Now()
+	subName := c.Param("subname")
+	topic := c.Param("topic")
+	msgid := c.Param("msgid")
+	req := &struct {
+		Deadline int64
+	}{}
+	if err := c.BindJSON(req); err != nil {
+		badRequest(c, err)
+		return
+	}
+	if req.Deadline < 0 {
+		badRequest(c, errors.New("deadline should not be negative"))
+		return
+	}
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	err := t.pubsub.ModifyAckDeadline(ctx, msgid, topic, subName, time.Duration(req.Deadline)*time.Second)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+	metrics.GetMetrics().MessagesHistogramVec.WithLabelValues("deadline").Observe(time.Since(start).Seconds())
+}
+
 // Subscribe a topic
+// the dead letter policy, the filter and the push endpoint of the subscription are updated if
+// they are given in the body, a new subscription starts from the Start in the body
+// or the latest message
 func (t *Server) Subscribe(c *gin.Context) {
 	start := time.Now()
 	subName := c.Param("subname")
 	topic := c.Param("topic")
+	req := &subscribeReq{}
+	// The body is optional
+	if err := c.ShouldBindJSON(req); err != nil && err != io.EOF {
+		badRequest(c, err)
+		return
+	}
+	if err := req.validate(topic); err != nil {
+		badRequest(c, err)
+		return
+	}
 	ctx, cancel := context.WithCancel(t.ctx)
 	defer cancel()
-	index, err := t.pubsub.Subscribe(ctx, subName, topic)
+	index, err := subscribe(ctx, t.pubsub, subName, topic, req)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, index)
 	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("sub").Observe(time.Since(start).Seconds())
 }
 
+// subscribeReq is the options of a subscription which are updated if they are given
+type subscribeReq struct {
+	DeadLetterTopic     *string
+	MaxDeliveryAttempts int
+	// Filter replaces the filter if it is given, an empty one clears the filter
+	Filter *string
+	// PushEndpoint replaces the push endpoint if it is given, an empty one makes
+	// the subscription a pull one
+	PushEndpoint *string
+	// Ordered enables or disables the ordered delivery if it is given
+	Ordered *bool
+	// Start is used only if the subscription is created
+	Start *startPosition
+}
+
+// startPosition is where a new subscription starts, such as {"Earliest":true},
+// {"Time":"2018-10-01T00:00:00Z"}, {"Offset":"{ts}-{index}"} or
+// {"Snapshot":"snap","SnapshotSubscription":"s1"}
+type startPosition struct {
+	Earliest             bool
+	Time                 *time.Time
+	Offset               string
+	Snapshot             string
+	SnapshotSubscription string
+}
+
+func (p *startPosition) position() (*tips.StartPosition, error) {
+	if p == nil {
+		return nil, nil
+	}
+	pos := &tips.StartPosition{
+		Earliest:             p.Earliest,
+		Snapshot:             p.Snapshot,
+		SnapshotSubscription: p.SnapshotSubscription,
+	}
+	if p.Time != nil {
+		pos.Time = *p.Time
+	}
+	if p.Offset != "" {
+		offset, err := pubsub.OffsetFromString(p.Offset)
+		if err != nil {
+			return nil, err
+		}
+		pos.Offset = offset
+	}
+	return pos, nil
+}
+
+// validate checks the options before subscribing the topic
+func (req *subscribeReq) validate(topic string) error {
+	if req.DeadLetterTopic != nil && *req.DeadLetterTopic != "" {
+		if req.MaxDeliveryAttempts <= 0 {
+			return errors.New("max delivery attempts should be greater than 0")
+		}
+		if *req.DeadLetterTopic == topic {
+			return errors.New("dead letter topic should not be the topic itself")
+		}
+	}
+	if req.Filter != nil {
+		if _, err := tips.ParseFilter(*req.Filter); err != nil {
+			return err
+		}
+	}
+	pos, err := req.Start.position()
+	if err != nil {
+		return err
+	}
+	if pos != nil {
+		if err := pos.Validate(); err != nil {
+			return err
+		}
+	}
+	if req.PushEndpoint != nil {
+		return tips.ValidatePushEndpoint(*req.PushEndpoint)
+	}
+	return nil
+}
+
+// subscribe subscribes a topic and updates the options of the subscription in one transaction
+func subscribe(ctx context.Context, pubsub *tips.Tips, subName, topic string, req *subscribeReq) (*tips.Subscription, error) {
+	start, err := req.Start.position()
+	if err != nil {
+		return nil, err
+	}
+	return pubsub.SubscribeWithOptions(ctx, subName, topic, &tips.SubscribeOptions{
+		Start:               start,
+		DeadLetterTopic:     req.DeadLetterTopic,
+		MaxDeliveryAttempts: req.MaxDeliveryAttempts,
+		Filter:              req.Filter,
+		PushEndpoint:        req.PushEndpoint,
+		Ordered:             req.Ordered,
+	})
+}
+
 // Unsubscribe a topic and subscription
 func (t *Server) Unsubscribe(c *gin.Context) {
 	start := time.Now()
@@ -149,17 +445,50 @@ func (t *Server) Unsubscribe(c *gin.Context) {
 	defer cancel()
 	err := t.pubsub.Unsubscribe(ctx, subName, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
 	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("unsub").Observe(time.Since(start).Seconds())
 }
 
+// Subscription returns a subscription with its backlog, including the number of
+// unacked messages, the age of the oldest one and the head offset of the topic
+func (t *Server) Subscription(c *gin.Context) {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	info, err := t.pubsub.Subscription(ctx, c.Param("subname"), c.Param("topic"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("subscription").Observe(time.Since(start).Seconds())
+}
+
+// ListSubscriptions lists the subscriptions of a topic in pages like ListTopics
+func (t *Server) ListSubscriptions(c *gin.Context) {
+	start := time.Now()
+	prefix, token, limit, err := listQuery(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	subs, next, err := t.pubsub.ListSubscriptions(ctx, c.Param("topic"), prefix, token, limit)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, &struct {
+		Subscriptions []*tips.Subscription
+		NextToken     string
+	}{subs, next})
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+}
+
 // Pull messages of a topic from a given offset
 // forbid topic subName to be empty and limit must be greater than 0
 // the default timeout is 1s and the timeout unit is s
@@ -167,13 +496,14 @@ func (t *Server) Unsubscribe(c *gin.Context) {
 func (t *Server) Pull(c *gin.Context) {
 	start := time.Now()
 	req := &struct {
-		Limit   int64
-		Timeout int64
-		AutoACK bool
-		Offset  string
+		Limit       int64
+		Timeout     int64
+		AutoACK     bool
+		Offset      string
+		AckDeadline int64
 	}{}
 	if err := c.BindJSON(req); err != nil && err != io.EOF {
-		c.JSON(http.StatusBadRequest, err.Error())
+		badRequest(c, err)
 		return
 	}
 	if req.Limit <= 0 {
@@ -190,16 +520,14 @@ func (t *Server) Pull(c *gin.Context) {
 		Limit:   req.Limit,
 		AutoACK: req.AutoACK,
 		Offset:  req.Offset,
+		// AckDeadline is in seconds
+		AckDeadline: time.Duration(req.AckDeadline) * time.Second,
 	}
 	ctx, cancel := context.WithTimeout(t.ctx, t1)
 	defer cancel()
-	msgs, err := t.pull(ctx, pReq, t1)
+	msgs, err := waitPull(ctx, t.pubsub, pReq)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, msgs)
@@ -223,11 +551,7 @@ func (t *Server) CreateSnapshots(c *gin.Context) {
 	defer cancel()
 	_, err := t.pubsub.CreateSnapshots(ctx, name, subName, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, name)
@@ -244,11 +568,7 @@ func (t *Server) DeleteSnapshots(c *gin.Context) {
 	defer cancel()
 	err := t.pubsub.DeleteSnapshots(ctx, name, subName, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
-			return
-		}
-		fail(c, http.StatusInternalServerError, err)
+		fail(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -265,13 +585,73 @@ func (t *Server) Seek(c *gin.Context) {
 	defer cancel()
 	sub, err := t.pubsub.Seek(ctx, name, subName, topic)
 	if err != nil {
-		if ErrNotFound(err) {
-			fail(c, http.StatusNotFound, err)
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("seek").Observe(time.Since(start).Seconds())
+}
+
+// ListSnapshots lists the snapshots of a subscription in pages like ListTopics
+func (t *Server) ListSnapshots(c *gin.Context) {
+	start := time.Now()
+	prefix, token, limit, err := listQuery(c)
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	snapshots, next, err := t.pubsub.ListSnapshots(ctx, c.Param("subname"), c.Param("topic"), prefix, token, limit)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, &struct {
+		Snapshots []*tips.Snapshot
+		NextToken string
+	}{snapshots, next})
+	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("list").Observe(time.Since(start).Seconds())
+}
+
+// SeekTo resets a subscription to a time or an offset given in the body, such as
+// {"Time":"2018-10-01T00:00:00Z"} or {"Offset":"{ts}-{index}"}. The messages
+// published at or after the time, or after the offset, are redelivered.
+func (t *Server) SeekTo(c *gin.Context) {
+	start := time.Now()
+	req := &struct {
+		Time   *time.Time
+		Offset string
+	}{}
+	if err := c.BindJSON(req); err != nil {
+		badRequest(c, err)
+		return
+	}
+	if (req.Time == nil) == (req.Offset == "") {
+		badRequest(c, errors.New("either time or offset should be given"))
+		return
+	}
+
+	subName := c.Param("subname")
+	topic := c.Param("topic")
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	var sub *tips.Subscription
+	var err error
+	if req.Time != nil {
+		sub, err = t.pubsub.SeekToTime(ctx, subName, topic, *req.Time)
+	} else {
+		var offset *pubsub.Offset
+		if offset, err = pubsub.OffsetFromString(req.Offset); err != nil {
+			badRequest(c, err)
 			return
 		}
-		fail(c, http.StatusInternalServerError, err)
+		sub, err = t.pubsub.SeekToOffset(ctx, subName, topic, offset)
+	}
+	if err != nil {
+		fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, sub)
-	metrics.GetMetrics().SnapshotsHistogramVec.WithLabelValues("seek").Observe(time.Since(start).Seconds())
+	metrics.GetMetrics().SubscribtionsHistogramVec.WithLabelValues("seek").Observe(time.Since(start).Seconds())
 }
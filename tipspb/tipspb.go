@@ -0,0 +1,4 @@
+// Package tipspb is the gRPC API of tipsd generated from tips.proto
+package tipspb
+
+//go:generate protoc --go_out=plugins=grpc:. tips.proto